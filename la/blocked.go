@@ -0,0 +1,120 @@
+package la
+
+// the side of the square tiles MMDot works through,
+// 64 x 64 float64s (32KiB) per operand fits in L1/L2
+const blockSize = 64
+
+// strided is a read only view of a matrix's backing data where
+// element (i, j) is data[i*rs+j*cs], letting every layout be read
+// in place without reordering it
+type strided struct {
+	data   []float64
+	rows   int
+	cols   int
+	rs, cs int
+}
+
+func view(m Matrix) strided {
+	rows, cols := Dims(m)
+
+	if data, rs, cs, ok := Strides(m); ok {
+		return strided{data, rows, cols, rs, cs}
+	}
+
+	// other implementations are copied into row major order
+	data := make([]float64, rows*cols)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			data[i*cols+j] = *m.At(i, j)
+		}
+	}
+
+	return strided{data, rows, cols, cols, 1}
+}
+
+// blockedMMDot writes a * b into the row major out, which must be
+// zeroed. Tiles are visited so that every out element sums its
+// products in increasing k, the same order as Dot.
+func blockedMMDot(out []float64, a, b strided) {
+	n, K, p := a.rows, a.cols, b.cols
+
+	// when neither b's rows nor out's rows can be walked
+	// contiguously dot a's rows with b's columns instead
+	if b.cs != 1 && a.cs == 1 && b.rs == 1 {
+		dotMMDot(out, a, b)
+		return
+	}
+
+	for ii := 0; ii < n; ii += blockSize {
+		iEnd := minInt(ii+blockSize, n)
+
+		for kk := 0; kk < K; kk += blockSize {
+			kEnd := minInt(kk+blockSize, K)
+
+			for jj := 0; jj < p; jj += blockSize {
+				jEnd := minInt(jj+blockSize, p)
+
+				for i := ii; i < iEnd; i++ {
+					row := out[i*p : (i+1)*p]
+
+					for k := kk; k < kEnd; k++ {
+						aik := a.data[i*a.rs+k*a.cs]
+						bk := k * b.rs
+
+						if b.cs == 1 {
+							bRow := b.data[bk : bk+p]
+
+							for j := jj; j < jEnd; j++ {
+								row[j] += aik * bRow[j]
+							}
+
+							continue
+						}
+
+						for j := jj; j < jEnd; j++ {
+							row[j] += aik * b.data[bk+j*b.cs]
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// dotMMDot multiplies a with contiguous rows by b with
+// contiguous columns one dot product at a time
+func dotMMDot(out []float64, a, b strided) {
+	n, K, p := a.rows, a.cols, b.cols
+
+	for ii := 0; ii < n; ii += blockSize {
+		iEnd := minInt(ii+blockSize, n)
+
+		for jj := 0; jj < p; jj += blockSize {
+			jEnd := minInt(jj+blockSize, p)
+
+			for i := ii; i < iEnd; i++ {
+				aRow := a.data[i*a.rs : i*a.rs+K]
+
+				for j := jj; j < jEnd; j++ {
+					bCol := b.data[j*b.cs : j*b.cs+K]
+					sum := 0.0
+
+					for k, x := range aRow {
+						sum += x * bCol[k]
+					}
+
+					out[i*p+j] = sum
+				}
+			}
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,248 @@
+package la
+
+// The Into variants write their result into dst, which must already
+// have the shape of the result, and return it so nothing is allocated.
+// Element wise operations may use one of their inputs as dst. Matrices
+// created by ZeroMatrix and RandMatrix are written through their data,
+// other layouts element by element.
+
+// Axpy adds alpha * x to y in place
+func Axpy(alpha float64, x, y []float64) {
+	for i := range y {
+		y[i] += alpha * x[i]
+	}
+}
+
+// MAxpy adds alpha * x to the matrix y in place
+func MAxpy(alpha float64, x, y Matrix) {
+	if xd, yd, ok := rowMajor2(x, y); ok {
+		Axpy(alpha, xd, yd)
+		return
+	}
+
+	rows, cols := Dims(y)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*y.At(i, j) += alpha * *x.At(i, j)
+		}
+	}
+}
+
+// MZero sets every element of m to 0
+func MZero(m Matrix) Matrix {
+	return MMapInto(m, m, func(float64) float64 { return 0 })
+}
+
+func MapInto(dst, a []float64, op OP) []float64 {
+	for i := range a {
+		dst[i] = op(a[i])
+	}
+
+	return dst
+}
+
+func AggInto(dst, a, b []float64, op BOP) []float64 {
+	for i := range a {
+		dst[i] = op(a[i], b[i])
+	}
+
+	return dst
+}
+
+func MMapInto(dst, m Matrix, op OP) Matrix {
+	if dd, md, ok := rowMajor2(dst, m); ok {
+		MapInto(dd, md, op)
+		return dst
+	}
+
+	rows, cols := Dims(m)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*dst.At(i, j) = op(*m.At(i, j))
+		}
+	}
+
+	return dst
+}
+
+func MAggInto(dst, a, b Matrix, op BOP) Matrix {
+	if dd, ad, ok := rowMajor2(dst, a); ok {
+		if bd, ok := rowMajor(b); ok {
+			AggInto(dd, ad, bd, op)
+			return dst
+		}
+	}
+
+	rows, cols := Dims(a)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*dst.At(i, j) = op(*a.At(i, j), *b.At(i, j))
+		}
+	}
+
+	return dst
+}
+
+// AddAggInto adds op applied to a and b to dst in place
+func AddAggInto(dst, a, b []float64, op BOP) []float64 {
+	for i := range dst {
+		dst[i] += op(a[i], b[i])
+	}
+
+	return dst
+}
+
+// MAddAggInto adds op applied to a and b to the matrix dst in place
+func MAddAggInto(dst, a, b Matrix, op BOP) Matrix {
+	if dd, ad, ok := rowMajor2(dst, a); ok {
+		if bd, ok := rowMajor(b); ok {
+			AddAggInto(dd, ad, bd, op)
+			return dst
+		}
+	}
+
+	rows, cols := Dims(dst)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*dst.At(i, j) += op(*a.At(i, j), *b.At(i, j))
+		}
+	}
+
+	return dst
+}
+
+// MAddColVector adds v to every column of m in place
+func MAddColVector(m Matrix, v []float64) Matrix {
+	_, cols := Dims(m)
+
+	if md, ok := rowMajor(m); ok {
+		for i, x := range v {
+			row := md[i*cols : (i+1)*cols]
+
+			for j := range row {
+				row[j] += x
+			}
+		}
+
+		return m
+	}
+
+	for i, x := range v {
+		for j := 0; j < cols; j++ {
+			*m.At(i, j) += x
+		}
+	}
+
+	return m
+}
+
+// MMDotInto is MMDot written into dst
+func MMDotInto(dst, a, b Matrix) Matrix {
+	return mmDotInto(dst, view(a), view(b))
+}
+
+// TMMDotInto writes the product of the transpose of a and b into dst
+// without creating the transpose
+func TMMDotInto(dst, a, b Matrix) Matrix {
+	av := view(a)
+	return mmDotInto(dst, strided{av.data, av.cols, av.rows, av.cs, av.rs}, view(b))
+}
+
+func mmDotInto(dst Matrix, a, b strided) Matrix {
+	if dd, ok := rowMajor(dst); ok {
+		for i := range dd {
+			dd[i] = 0
+		}
+
+		blockedMMDot(dd, a, b)
+		return dst
+	}
+
+	out := make([]float64, a.rows*b.cols)
+	blockedMMDot(out, a, b)
+
+	for i := 0; i < a.rows; i++ {
+		for j := 0; j < b.cols; j++ {
+			*dst.At(i, j) = out[i*b.cols+j]
+		}
+	}
+
+	return dst
+}
+
+func MVDotInto(dst []float64, a Matrix, b []float64) []float64 {
+	for i := range dst {
+		dst[i] = Dot(a.Row(i), b)
+	}
+
+	return dst
+}
+
+// OuterInto writes the outer product of a and b into dst
+func OuterInto(dst Matrix, a, b []float64) Matrix {
+	for i := range a {
+		for j := range b {
+			*dst.At(i, j) = a[i] * b[j]
+		}
+	}
+
+	return dst
+}
+
+// MOuterColAvgInto is MOuterColAvg written into dst
+func MOuterColAvgInto(dst, a, b Matrix) Matrix {
+	av, bv := view(a), view(b)
+	n, m, N := av.rows, bv.rows, av.cols
+	scale := 1 / float64(N)
+
+	for i := 0; i < n; i++ {
+		for k := 0; k < m; k++ {
+			sum := 0.0
+
+			for j := 0; j < N; j++ {
+				sum += av.data[i*av.rs+j*av.cs] * bv.data[k*bv.rs+j*bv.cs]
+			}
+
+			*dst.At(i, k) = sum * scale
+		}
+	}
+
+	return dst
+}
+
+// RowAvgInto is RowAvg written into dst
+func RowAvgInto(dst []float64, a Matrix) []float64 {
+	av := view(a)
+	scale := 1 / float64(av.cols)
+
+	for i := range dst {
+		sum := 0.0
+
+		for j := 0; j < av.cols; j++ {
+			sum += av.data[i*av.rs+j*av.cs]
+		}
+
+		dst[i] = sum * scale
+	}
+
+	return dst
+}
+
+// rowMajor returns the data of a row major matrix
+func rowMajor(m Matrix) ([]float64, bool) {
+	if rm, ok := m.(matrix); ok {
+		return rm.data, true
+	}
+
+	return nil, false
+}
+
+func rowMajor2(a, b Matrix) ([]float64, []float64, bool) {
+	ad, aok := rowMajor(a)
+	bd, bok := rowMajor(b)
+	return ad, bd, aok && bok
+}
@@ -0,0 +1,90 @@
+package la_test
+
+import (
+	"testing"
+
+	. "github.com/hayden-erickson/neural-network/la"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Into", func() {
+	var x, y, z Matrix
+
+	BeforeEach(func() {
+		x = RandMatrix(70, 130)
+		y = RandMatrix(130, 65)
+		z = RandMatrix(70, 130)
+	})
+
+	It("computes exactly what the allocating operations do", func() {
+		for _, a := range allLayouts(gridOf(x)) {
+			for _, b := range allLayouts(gridOf(y)) {
+				Expect(MMDotInto(ZeroMatrix(70, 65), a, b)).To(Equal(MMDot(a, b)))
+			}
+		}
+
+		Expect(TMMDotInto(ZeroMatrix(130, 130), z, x)).To(Equal(MMDot(z.T(), x)))
+		Expect(MAggInto(ZeroMatrix(70, 130), x, z, SUB)).To(Equal(MAggD(x, z, SUB)))
+		Expect(MMapInto(ZeroMatrix(70, 130), x, MultBy(3))).To(Equal(MMapD(x, MultBy(3))))
+		Expect(MOuterColAvgInto(ZeroMatrix(70, 70), x, z)).To(Equal(MOuterColAvg(x, z)))
+		Expect(RowAvgInto(make([]float64, 70), x)).To(Equal(RowAvg(x)))
+
+		v, w := RandVector(130), RandVector(70)
+
+		Expect(MVDotInto(make([]float64, 70), x, v)).To(Equal(MVDot(x, v)))
+		Expect(OuterInto(ZeroMatrix(70, 130), w, v)).To(Equal(Outer(w, v)))
+		Expect(AggInto(make([]float64, 130), v, v, MULT)).To(Equal(VMULT(v, v)))
+		Expect(MapInto(make([]float64, 130), v, MultBy(2))).To(Equal(VSCALE(v, 2)))
+	})
+
+	It("writes into destinations of any layout", func() {
+		want := MMDot(x, y)
+
+		for _, dst := range allLayouts(gridOf(ZeroMatrix(70, 65))) {
+			MMDotInto(dst, x, y)
+
+			for i := 0; i < 70; i++ {
+				for j := 0; j < 65; j++ {
+					Expect(*dst.At(i, j)).To(Equal(*want.At(i, j)))
+				}
+			}
+		}
+	})
+
+	It("updates matrices in place", func() {
+		want := MSUM(x, MSCALE(z, -0.5))
+
+		MAxpy(-0.5, z, x)
+		Expect(x).To(Equal(want))
+
+		want = MSUM(x, MAggD(x, z, MULT))
+		MAddAggInto(x, x, z, MULT)
+		Expect(x).To(Equal(want))
+
+		v := []float64{1, 2, 3}
+		Axpy(2, []float64{1, 1, 1}, v)
+		Expect(v).To(Equal([]float64{3, 4, 5}))
+
+		m := MAddColVector(NewMatrix([][]float64{{1, 2}, {3, 4}}, false), []float64{10, 20})
+		Expect(m).To(Equal(NewMatrix([][]float64{{11, 12}, {23, 24}}, false)))
+
+		Expect(MZero(x)).To(Equal(ZeroMatrix(70, 130)))
+	})
+})
+
+func BenchmarkMMDotInto(b *testing.B) {
+	dst := ZeroMatrix(100, 100)
+
+	for i := 0; i < b.N; i++ {
+		MMDotInto(dst, pw1, pbatch)
+	}
+}
+
+func BenchmarkTMMDotInto(b *testing.B) {
+	dst := ZeroMatrix(784, 100)
+
+	for i := 0; i < b.N; i++ {
+		TMMDotInto(dst, pw1, pdelta)
+	}
+}
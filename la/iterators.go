@@ -44,12 +44,13 @@ func Agg(a, b []float64, op BOP) []float64 {
 }
 
 // a binary operation for two matricies
+// of the same shape
 func MAgg(a, b Matrix, op BOP) Matrix {
-	m := matrix{x: a.Shape()[0], y: b.Shape()[1]}
-	m.data = make([]float64, a.Shape()[0]*a.Shape()[1])
+	rows, cols := Dims(a)
+	m := matrix{x: rows, y: cols, data: make([]float64, rows*cols)}
 
-	for i := 0; i < a.Shape()[0]; i++ {
-		for j := 0; j < a.Shape()[1]; j++ {
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
 			*m.At(i, j) = op(*a.At(i, j), *b.At(i, j))
 		}
 	}
@@ -63,8 +64,8 @@ func MAgg(a, b Matrix, op BOP) Matrix {
 // this method yields much better performance which can be seen in the
 // corresponding benchmarks
 func MAggD(a, b Matrix, op BOP) Matrix {
-	out := matrix{x: a.Shape()[0], y: b.Shape()[1]}
-	out.data = make([]float64, a.Shape()[0]*a.Shape()[1])
+	rows, cols := Dims(a)
+	out := matrix{x: rows, y: cols, data: make([]float64, rows*cols)}
 
 	aData := a.Data()
 	bData := b.Data()
@@ -102,13 +103,16 @@ func MReduce(a []Matrix, op BOP) Matrix {
 // a special mapper which passes along
 // the current index to the operator
 func MMapI(m Matrix, op IOP) Matrix {
-	for i := 0; i < m.Shape()[0]; i++ {
-		for j := 0; j < m.Shape()[1]; j++ {
-			*m.At(i, j) = op(*m.At(i, j), i, j)
+	rows, cols := Dims(m)
+	out := matrix{x: rows, y: cols, data: make([]float64, rows*cols)}
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*out.At(i, j) = op(*m.At(i, j), i, j)
 		}
 	}
 
-	return m
+	return out
 }
 
 // M = matrix, Map, I = indexed, D = data
@@ -134,18 +138,19 @@ func MMapID(m Matrix, op IOP) Matrix {
 
 // M = matrix, Map
 // apply the unary operator element wise to
-// the given matrix
+// the given matrix, use MMapInto(m, m, op)
+// to apply it in place
 func MMap(m Matrix, op OP) Matrix {
-	x := m.Shape()[0]
-	y := m.Shape()[1]
+	x, y := Dims(m)
+	out := matrix{x: x, y: y, data: make([]float64, x*y)}
 
 	for i := 0; i < x; i++ {
 		for j := 0; j < y; j++ {
-			*m.At(i, j) = op(*m.At(i, j))
+			*out.At(i, j) = op(*m.At(i, j))
 		}
 	}
 
-	return m
+	return out
 }
 
 // M = matrix, Map, D = data
@@ -166,11 +171,22 @@ func MMapD(m Matrix, op OP) Matrix {
 }
 
 // M = matrix, Map, D = data, P = parallel
-// use the par for loop with a branch factor
-// of two (i.e. 2^2 = 4 threads)
+// the data is split across the package's Workers
 func MMapDP(m Matrix, op OP) Matrix {
-	parallel.For(m.Data(), 2, op)
-	return m
+	data := m.Data()
+	out := make([]float64, len(data))
+
+	parallel.Range(len(data), workers(len(data)), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			out[i] = op(data[i])
+		}
+	})
+
+	return matrix{
+		x:    m.Shape()[0],
+		y:    m.Shape()[1],
+		data: out,
+	}
 }
 
 // M = matrix, V = vector, Dot product
@@ -185,15 +201,16 @@ func MVDot(a Matrix, b []float64) []float64 {
 }
 
 // M = matrix, M = matrix, Dot product
+// the product of a and b as a row major matrix. Every layout is
+// read in place and multiplied tile by tile, see blockedMMDot.
 func MMDot(a, b Matrix) Matrix {
-	out := ZeroMatrix(a.Shape()[0], b.Shape()[1])
-
-	for i := 0; i < a.Shape()[0]; i++ {
-		for j := 0; j < b.Shape()[1]; j++ {
-			*out.At(i, j) = Dot(a.Row(i), b.Col(j))
-		}
+	out := matrix{
+		x:    a.Shape()[0],
+		y:    b.Shape()[1],
+		data: make([]float64, a.Shape()[0]*b.Shape()[1]),
 	}
 
+	blockedMMDot(out.data, view(a), view(b))
 	return out
 }
 
@@ -139,3 +139,59 @@ func BenchmarkMAggD(b *testing.B) {
 	}
 
 }
+
+// the shapes of a 784-100-10 network on mini batches of 10
+var w1 = RandMatrix(100, 784)
+var w2 = RandMatrix(10, 100)
+var batch = RandMatrix(784, 10)
+var hidden = RandMatrix(100, 10)
+var delta = RandMatrix(10, 10)
+
+// the product as MMDot computed it before it was blocked
+func dotMMDot(a, b Matrix) Matrix {
+	out := ZeroMatrix(a.Shape()[0], b.Shape()[1])
+
+	for i := 0; i < a.Shape()[0]; i++ {
+		for j := 0; j < b.Shape()[1]; j++ {
+			*out.At(i, j) = Dot(a.Row(i), b.Col(j))
+		}
+	}
+
+	return out
+}
+
+func BenchmarkMMDotForward(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MMDot(w1, batch)
+	}
+}
+
+func BenchmarkDotMMDotForward(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dotMMDot(w1, batch)
+	}
+}
+
+func BenchmarkMMDotBackward(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MMDot(w2.T(), delta)
+	}
+}
+
+func BenchmarkDotMMDotBackward(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dotMMDot(w2.T(), delta)
+	}
+}
+
+func BenchmarkMMDotOutput(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MMDot(w2, hidden)
+	}
+}
+
+func BenchmarkDotMMDotOutput(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		dotMMDot(w2, hidden)
+	}
+}
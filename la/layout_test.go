@@ -0,0 +1,328 @@
+package la_test
+
+import (
+	"math/rand"
+
+	. "github.com/hayden-erickson/neural-network/la"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// grid is the reference a Matrix is checked against, the
+// elements as rows computed one at a time
+type grid [][]float64
+
+func randGrid(r *rand.Rand, rows, cols int) grid {
+	g := make(grid, rows)
+
+	for i := range g {
+		g[i] = make([]float64, cols)
+
+		for j := range g[i] {
+			g[i][j] = r.NormFloat64()
+		}
+	}
+
+	return g
+}
+
+func (g grid) t() grid {
+	out := make(grid, len(g[0]))
+
+	for j := range out {
+		out[j] = make([]float64, len(g))
+
+		for i := range g {
+			out[j][i] = g[i][j]
+		}
+	}
+
+	return out
+}
+
+func (g grid) col(j int) []float64 {
+	return g.t()[j]
+}
+
+func (g grid) flat() []float64 {
+	var out []float64
+
+	for _, row := range g {
+		out = append(out, row...)
+	}
+
+	return out
+}
+
+func (g grid) mapI(op func(x float64, i, j int) float64) grid {
+	out := make(grid, len(g))
+
+	for i := range g {
+		out[i] = make([]float64, len(g[i]))
+
+		for j := range g[i] {
+			out[i][j] = op(g[i][j], i, j)
+		}
+	}
+
+	return out
+}
+
+func (g grid) agg(h grid, op BOP) grid {
+	return g.mapI(func(x float64, i, j int) float64 {
+		return op(x, h[i][j])
+	})
+}
+
+func (g grid) dot(h grid) grid {
+	out := make(grid, len(g))
+
+	for i := range g {
+		out[i] = make([]float64, len(h[0]))
+
+		for j := range out[i] {
+			for k := range h {
+				out[i][j] += g[i][k] * h[k][j]
+			}
+		}
+	}
+
+	return out
+}
+
+func (g grid) rowAvg() []float64 {
+	out := make([]float64, len(g))
+
+	for i, row := range g {
+		for _, x := range row {
+			out[i] += x
+		}
+
+		out[i] /= float64(len(row))
+	}
+
+	return out
+}
+
+// gridOf reads m back one element at a time
+func gridOf(m Matrix) grid {
+	out := make(grid, m.Shape()[0])
+
+	for i := range out {
+		out[i] = make([]float64, m.Shape()[1])
+
+		for j := range out[i] {
+			out[i][j] = *m.At(i, j)
+		}
+	}
+
+	return out
+}
+
+// every way to hold g: row major, column major, the transposes of
+// both holding g's transpose and a transpose of a transpose
+func allLayouts(g grid) []Matrix {
+	rows, cols := len(g), len(g[0])
+
+	return []Matrix{
+		NewMatrix(g, false),
+		NewColMatrix(rows, cols, g.t().flat()),
+		NewMatrix(g.t(), false).T(),
+		NewColMatrix(cols, rows, g.flat()).T(),
+		NewMatrix(g, false).T().T(),
+	}
+}
+
+func expectGrid(m Matrix, want grid) {
+	ExpectWithOffset(1, m.Shape()).To(Equal([]int{len(want), len(want[0])}))
+	expectClose(1, gridOf(m).flat(), want.flat())
+}
+
+func expectClose(offset int, actual, want []float64) {
+	ExpectWithOffset(offset+1, actual).To(HaveLen(len(want)))
+
+	for i := range want {
+		ExpectWithOffset(offset+1, actual[i]).To(BeNumerically(`~`, want[i], 1e-12))
+	}
+}
+
+var _ = Describe("Layouts", func() {
+	const trials = 25
+
+	var r *rand.Rand
+	var workers, threshold int
+
+	BeforeEach(func() {
+		r = rand.New(rand.NewSource(1))
+		workers, threshold = Workers, Threshold
+		Workers, Threshold = 3, 0
+	})
+
+	AfterEach(func() {
+		Workers, Threshold = workers, threshold
+	})
+
+	// shape returns random dimensions from 1 to 9
+	shape := func() (int, int) {
+		return r.Intn(9) + 1, r.Intn(9) + 1
+	}
+
+	It("agree on every element", func() {
+		for trial := 0; trial < trials; trial++ {
+			rows, cols := shape()
+			g := randGrid(r, rows, cols)
+
+			for _, m := range allLayouts(g) {
+				Expect(m.Shape()).To(Equal([]int{rows, cols}))
+				Expect(gridOf(m)).To(Equal(g))
+				Expect(gridOf(m.T())).To(Equal(g.t()))
+				Expect(m.Data()).To(Equal(g.flat()))
+
+				dr, dc := Dims(m)
+				Expect([]int{dr, dc}).To(Equal(m.Shape()))
+
+				for i := range g {
+					Expect(m.Row(i)).To(Equal(g[i]))
+				}
+
+				for j := range g[0] {
+					Expect(m.Col(j)).To(Equal(g.col(j)))
+				}
+
+				data, rs, cs, ok := Strides(m)
+				Expect(ok).To(BeTrue())
+
+				for i := range g {
+					for j := range g[i] {
+						Expect(data[i*rs+j*cs]).To(Equal(g[i][j]))
+					}
+				}
+
+				// the transpose shares the matrix's storage
+				*m.T().At(cols-1, 0) = 7
+				Expect(*m.At(0, cols-1)).To(Equal(7.0))
+				*m.At(0, cols-1) = g[0][cols-1]
+			}
+		}
+	})
+
+	It("report their layout", func() {
+		m := RandMatrix(3, 4)
+
+		Expect(LayoutOf(m)).To(Equal(RowMajor))
+		Expect(LayoutOf(m.T())).To(Equal(ColMajor))
+		Expect(LayoutOf(NewMatrix(gridOf(m), true))).To(Equal(ColMajor))
+		Expect(LayoutOf(NewMatrix(gridOf(m), true).T())).To(Equal(RowMajor))
+	})
+
+	It("give the same results from every element wise operation", func() {
+		scale := MultBy(3)
+		indexed := func(x float64, is ...int) float64 {
+			return x + float64(10*is[0]+is[1])
+		}
+
+		for trial := 0; trial < trials; trial++ {
+			rows, cols := shape()
+			g, h := randGrid(r, rows, cols), randGrid(r, rows, cols)
+			sum, product := g.agg(h, SUM), g.agg(h, MULT)
+			scaled := g.mapI(func(x float64, _, _ int) float64 { return scale(x) })
+			withIndex := g.mapI(func(x float64, i, j int) float64 { return indexed(x, i, j) })
+
+			for _, a := range allLayouts(g) {
+				expectGrid(MMap(a, scale), scaled)
+				expectGrid(MMapD(a, scale), scaled)
+				expectGrid(MMapDP(a, scale), scaled)
+				expectGrid(MSCALE(a, 3), scaled)
+				expectGrid(MMapI(a, indexed), withIndex)
+				expectGrid(MMapID(a, indexed), withIndex)
+				expectGrid(MMapInto(ZeroMatrix(rows, cols), a, scale), scaled)
+				expectClose(0, RowAvg(a), g.rowAvg())
+				expectClose(0, RowAvgP(a), g.rowAvg())
+				expectClose(0, RowAvgInto(make([]float64, rows), a), g.rowAvg())
+
+				for _, b := range allLayouts(h) {
+					expectGrid(MAgg(a, b, SUM), sum)
+					expectGrid(MAggD(a, b, SUM), sum)
+					expectGrid(MAggDP(a, b, SUM), sum)
+					expectGrid(MSUM(a, b), sum)
+					expectGrid(MMULT(a, b), product)
+					expectGrid(MReduce([]Matrix{a, b}, SUM), sum)
+
+					for _, dst := range allLayouts(randGrid(r, rows, cols)) {
+						expectGrid(MAggInto(dst, a, b, MULT), product)
+					}
+				}
+
+				// no operation modifies its arguments
+				expectGrid(a, g)
+			}
+		}
+	})
+
+	It("give the same products", func() {
+		for trial := 0; trial < trials; trial++ {
+			n, k := shape()
+			p := r.Intn(9) + 1
+			g, h := randGrid(r, n, k), randGrid(r, k, p)
+			v := randGrid(r, k, 1).col(0)
+			product := g.dot(h)
+
+			// the outer product averaged over the columns of g and f
+			f := randGrid(r, p, k)
+			outer := g.dot(f.t()).mapI(func(x float64, _, _ int) float64 { return x / float64(k) })
+
+			for _, a := range allLayouts(g) {
+				for _, b := range allLayouts(h) {
+					expectGrid(MMDot(a, b), product)
+					expectGrid(MMDotP(a, b), product)
+
+					for _, dst := range allLayouts(randGrid(r, n, p)) {
+						expectGrid(MMDotInto(dst, a, b), product)
+					}
+
+					expectGrid(TMMDotInto(ZeroMatrix(p, n), b, a.T()), product.t())
+				}
+
+				for _, b := range allLayouts(f) {
+					expectGrid(MOuterColAvg(a, b), outer)
+					expectGrid(MOuterColAvgP(a, b), outer)
+					expectGrid(MOuterColAvgInto(ZeroMatrix(n, p), a, b), outer)
+				}
+
+				want := g.dot(grid{v}.t()).col(0)
+				expectClose(0, MVDot(a, v), want)
+				expectClose(0, MVDotP(a, v), want)
+				expectClose(0, MVDotInto(make([]float64, n), a, v), want)
+				expectGrid(a, g)
+			}
+		}
+	})
+
+	It("update every layout in place", func() {
+		for trial := 0; trial < trials; trial++ {
+			rows, cols := shape()
+			g, h := randGrid(r, rows, cols), randGrid(r, rows, cols)
+			v := randGrid(r, 1, rows)[0]
+
+			axpy := g.agg(h, func(y, x float64) float64 { return y + -2*x })
+			addAgg := g.agg(h.agg(h, MULT), SUM)
+			addCol := g.mapI(func(x float64, i, _ int) float64 { return x + v[i] })
+
+			for i := range allLayouts(g) {
+				for _, x := range allLayouts(h) {
+					y := allLayouts(g)[i]
+					MAxpy(-2, x, y)
+					expectGrid(y, axpy)
+
+					y = allLayouts(g)[i]
+					MAddAggInto(y, x, x, MULT)
+					expectGrid(y, addAgg)
+				}
+
+				expectGrid(MAddColVector(allLayouts(g)[i], v), addCol)
+				expectGrid(MZero(allLayouts(g)[i]), randGrid(r, rows, cols).mapI(
+					func(float64, int, int) float64 { return 0 }))
+			}
+		}
+	})
+})
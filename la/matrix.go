@@ -1,5 +1,22 @@
 package la
 
+// A Matrix is a 2-D grid of float64s with Shape rows and columns,
+// stored in one of two layouts:
+//
+//   - row major (RowMajor), created by ZeroMatrix, RandMatrix, NewMatrix
+//     and every operation of this package
+//   - column major (ColMajor), created by NewColMatrix and NewMatrix
+//
+// T is the transpose, which shares the storage of the matrix and so
+// has the other layout. Row, Col and Data return the elements in
+// row major order; they share storage with the matrix when those
+// elements are contiguous in it and are copies otherwise, so they
+// should only be written to through At or the Into functions.
+//
+// Operations never modify their arguments and return a new row major
+// matrix. The exceptions say so and are named for it: the Into
+// functions write to a destination and functions such as MAxpy,
+// MAddColVector and MZero update a matrix in place.
 type Matrix interface {
 	T() Matrix
 	Row(int) []float64
@@ -53,11 +70,11 @@ func (cm colmajmatrix) T() Matrix {
 	}
 }
 
-func (cm colmajmatrix) Row(j int) []float64 {
+func (cm colmajmatrix) Row(i int) []float64 {
 	out := make([]float64, cm.y)
 
-	for i := 0; i < cm.y; i++ {
-		out[i] = cm.data[i*cm.x+j]
+	for j := 0; j < cm.y; j++ {
+		out[j] = cm.data[j*cm.x+i]
 	}
 
 	return out
@@ -75,8 +92,8 @@ func (cm colmajmatrix) Shape() []int {
 	return []int{cm.x, cm.y}
 }
 
-// return the data in row major order
-// for compatibility with data based
+// return a copy of the data in row major
+// order for compatibility with data based
 // iterators using 1-dimensional
 // array indexing for speed
 func (cm colmajmatrix) Data() []float64 {
@@ -85,18 +102,20 @@ func (cm colmajmatrix) Data() []float64 {
 	for i := 0; i < cm.x*cm.y; i++ {
 		I := i / cm.y
 		J := i % cm.y
-		out[I*cm.y+J] = cm.data[i]
+		out[i] = cm.data[J*cm.x+I]
 	}
 
 	return out
 }
 
+// a copy of the data in row major order, the
+// transpose has t.m.x columns
 func (t transposer) Data() []float64 {
 	out := make([]float64, t.m.x*t.m.y)
 
 	for i := 0; i < t.m.x*t.m.y; i++ {
-		I := i / t.m.y
-		J := i % t.m.y
+		I := i / t.m.x
+		J := i % t.m.x
 		out[i] = t.m.data[J*t.m.y+I]
 	}
 
@@ -133,4 +152,54 @@ func (m matrix) Data() []float64 {
 	return m.data
 }
 
+// Layout is the order a matrix stores its elements in
+type Layout int
+
+const (
+	RowMajor Layout = iota
+	ColMajor
+)
+
+// LayoutOf is the layout of m. Matrices from outside
+// this package are treated as row major, like Data.
+func LayoutOf(m Matrix) Layout {
+	switch m.(type) {
+	case colmajmatrix, transposer:
+		return ColMajor
+	}
+
+	return RowMajor
+}
+
+// Strides returns the storage of m and the distance in it between
+// consecutive rows (rs) and columns (cs), so element (i, j) is
+// data[i*rs+j*cs]. ok is false for matrices from outside this package.
+func Strides(m Matrix) (data []float64, rs, cs int, ok bool) {
+	switch t := m.(type) {
+	case matrix:
+		return t.data, t.y, 1, true
+	case colmajmatrix:
+		return t.data, 1, t.x, true
+	case transposer:
+		return t.m.data, 1, t.m.y, true
+	}
+
+	return nil, 0, 0, false
+}
+
+// Dims is the number of rows and columns of m, which unlike
+// Shape doesn't allocate for the matrices of this package
+func Dims(m Matrix) (rows, cols int) {
+	switch t := m.(type) {
+	case matrix:
+		return t.x, t.y
+	case colmajmatrix:
+		return t.x, t.y
+	case transposer:
+		return t.m.y, t.m.x
+	}
+
+	return m.Shape()[0], m.Shape()[1]
+}
+
 // func CreateMatrixAgg
@@ -1,6 +1,8 @@
 package la_test
 
 import (
+	"math/rand"
+
 	. "github.com/hayden-erickson/neural-network/la"
 
 	. "github.com/onsi/ginkgo"
@@ -19,7 +21,8 @@ var _ = Describe("Matrix", func() {
 		}
 		m = NewMatrix(data, false)
 
-		cm = NewMatrix(data, true)
+		// the rows of data as columns
+		cm = NewColMatrix(3, 3, grid(data).flat())
 	})
 
 	Describe("#At", func() {
@@ -34,6 +37,18 @@ var _ = Describe("Matrix", func() {
 			Expect(data[2][0]).To(Equal(*cm.At(0, 2)))
 		})
 
+		It("reads the rows of data as rows in either layout", func() {
+			colmaj := NewMatrix(data, true)
+
+			Expect(LayoutOf(colmaj)).To(Equal(ColMajor))
+
+			for i := range data {
+				for j := range data[i] {
+					Expect(*colmaj.At(i, j)).To(Equal(data[i][j]))
+				}
+			}
+		})
+
 		It("sets the value when assigned", func() {
 			setTo := 4.0
 			Expect(*m.At(0, 2)).ToNot(Equal(setTo))
@@ -146,5 +161,18 @@ var _ = Describe("Matrix", func() {
 				Expect(c.Row(i)).To(Equal(data[i]))
 			}
 		})
+
+		It("multiplies every combination of layouts larger than a tile", func() {
+			r := rand.New(rand.NewSource(1))
+			a := randGrid(r, 70, 130)
+			b := randGrid(r, 130, 65)
+			expected := a.dot(b)
+
+			for _, x := range allLayouts(a) {
+				for _, y := range allLayouts(b) {
+					expectGrid(MMDot(x, y), expected)
+				}
+			}
+		})
 	})
 })
@@ -0,0 +1,115 @@
+package la
+
+import (
+	"runtime"
+
+	"github.com/hayden-erickson/neural-network/parallel"
+)
+
+// Workers is the number of goroutines the parallel (P) kernels
+// split their work across
+var Workers = runtime.NumCPU()
+
+// Threshold is the amount of work (multiply adds or elements) below
+// which the parallel kernels run serially, as the cost of starting
+// goroutines outweighs what they save
+var Threshold = 1 << 14
+
+// workers is the number of goroutines for the given amount of work
+func workers(work int) int {
+	if work < Threshold {
+		return 1
+	}
+
+	return Workers
+}
+
+// the parallel form of MMDot, the rows of the
+// product are split across the workers
+func MMDotP(a, b Matrix) Matrix {
+	n, K, p := a.Shape()[0], a.Shape()[1], b.Shape()[1]
+	out := matrix{x: n, y: p, data: make([]float64, n*p)}
+	av, bv := view(a), view(b)
+
+	parallel.Range(n, workers(n*K*p), func(_, start, end int) {
+		blockedMMDot(out.data[start*p:end*p], av.sliceRows(start, end), bv)
+	})
+
+	return out
+}
+
+// sliceRows is the view of rows [start, end)
+func (s strided) sliceRows(start, end int) strided {
+	return strided{s.data[start*s.rs:], end - start, s.cols, s.rs, s.cs}
+}
+
+// the parallel form of MVDot
+func MVDotP(a Matrix, b []float64) []float64 {
+	n := a.Shape()[0]
+	out := make([]float64, n)
+
+	parallel.Range(n, workers(n*len(b)), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			out[i] = Dot(a.Row(i), b)
+		}
+	})
+
+	return out
+}
+
+// the parallel form of MAggD
+func MAggDP(a, b Matrix, op BOP) Matrix {
+	rows, cols := Dims(a)
+	out := matrix{x: rows, y: cols, data: make([]float64, rows*cols)}
+
+	aData := a.Data()
+	bData := b.Data()
+
+	parallel.Range(len(aData), workers(len(aData)), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			out.data[i] = op(aData[i], bData[i])
+		}
+	})
+
+	return out
+}
+
+// the parallel form of MOuterColAvg, every row of the
+// average is summed over the columns by one worker
+func MOuterColAvgP(a, b Matrix) Matrix {
+	n, m, N := a.Shape()[0], b.Shape()[0], a.Shape()[1]
+	out := matrix{x: n, y: m, data: make([]float64, n*m)}
+	av, bv := view(a), view(b)
+	scale := 1 / float64(N)
+
+	parallel.Range(n, workers(n*m*N), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			for k := 0; k < m; k++ {
+				sum := 0.0
+
+				for j := 0; j < N; j++ {
+					sum += av.data[i*av.rs+j*av.cs] * bv.data[k*bv.rs+j*bv.cs]
+				}
+
+				out.data[i*m+k] = sum * scale
+			}
+		}
+	})
+
+	return out
+}
+
+// the parallel form of RowAvg
+func RowAvgP(a Matrix) []float64 {
+	n := a.Shape()[0]
+	out := make([]float64, n)
+	scale := 1 / float64(a.Shape()[1])
+
+	parallel.Range(n, workers(n*a.Shape()[1]), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			out[i] = AddReduce(a.Row(i)) * scale
+		}
+	})
+
+	return out
+}
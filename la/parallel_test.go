@@ -0,0 +1,118 @@
+package la_test
+
+import (
+	"testing"
+
+	. "github.com/hayden-erickson/neural-network/la"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parallel", func() {
+	var workers, threshold int
+	var x, y, z Matrix
+
+	BeforeEach(func() {
+		workers, threshold = Workers, Threshold
+		// always split, with shards of uneven size
+		Workers, Threshold = 3, 0
+
+		x = RandMatrix(70, 130)
+		y = RandMatrix(130, 65)
+		z = RandMatrix(70, 130)
+	})
+
+	AfterEach(func() {
+		Workers, Threshold = workers, threshold
+	})
+
+	It("computes exactly what the serial kernels do", func() {
+		for _, a := range allLayouts(gridOf(x)) {
+			for _, b := range allLayouts(gridOf(y)) {
+				Expect(MMDotP(a, b)).To(Equal(MMDot(a, b)))
+			}
+		}
+
+		v := RandVector(130)
+
+		Expect(MVDotP(x, v)).To(Equal(MVDot(x, v)))
+		Expect(MAggDP(x, z, MULT)).To(Equal(MAggD(x, z, MULT)))
+		Expect(MOuterColAvgP(x, z)).To(Equal(MOuterColAvg(x, z)))
+		Expect(RowAvgP(x)).To(Equal(RowAvg(x)))
+		Expect(MMapDP(x, MultBy(2))).To(Equal(MMapD(x, MultBy(2))))
+	})
+
+	It("runs serially below the threshold", func() {
+		Threshold = 1 << 30
+
+		Expect(MMDotP(x, y)).To(Equal(MMDot(x, y)))
+		Expect(RowAvgP(x)).To(Equal(RowAvg(x)))
+	})
+})
+
+// the shapes of a 784-100-10 network on mini batches of 100
+var pw1 = RandMatrix(100, 784)
+var pbatch = RandMatrix(784, 100)
+var pactivations = RandMatrix(100, 100)
+var pdelta = RandMatrix(100, 100)
+var pv = RandVector(784)
+
+func BenchmarkMMDotSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MMDot(pw1, pbatch)
+	}
+}
+
+func BenchmarkMMDotP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MMDotP(pw1, pbatch)
+	}
+}
+
+func BenchmarkMVDotSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MVDot(pw1, pv)
+	}
+}
+
+func BenchmarkMVDotP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MVDotP(pw1, pv)
+	}
+}
+
+func BenchmarkMAggDSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MAggD(m, m, SUM)
+	}
+}
+
+func BenchmarkMAggDP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MAggDP(m, m, SUM)
+	}
+}
+
+func BenchmarkMOuterColAvgSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MOuterColAvg(pdelta, pactivations)
+	}
+}
+
+func BenchmarkMOuterColAvgP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		MOuterColAvgP(pdelta, pactivations)
+	}
+}
+
+func BenchmarkRowAvgSerial(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		RowAvg(m)
+	}
+}
+
+func BenchmarkRowAvgP(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		RowAvgP(m)
+	}
+}
@@ -0,0 +1,116 @@
+package la
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrShapeMismatch is matched, through errors.Is, by the ShapeError
+// returned when the arguments of an operation have incompatible shapes
+var ErrShapeMismatch = errors.New(`Incompatible shapes`)
+
+// A ShapeError is the operation and shapes of arguments which can't
+// be used together. Vectors have a single dimension, their length.
+type ShapeError struct {
+	Op string
+	A  []int
+	B  []int
+}
+
+func (e *ShapeError) Error() string {
+	return fmt.Sprintf(`%s: incompatible shapes %v and %v`, e.Op, e.A, e.B)
+}
+
+func (e *ShapeError) Is(target error) bool {
+	return target == ErrShapeMismatch
+}
+
+func vectorShape(v []float64) []int {
+	return []int{len(v)}
+}
+
+// The checked (C) variants below return a *ShapeError instead of
+// panicking or reading past their arguments when the shapes of the
+// arguments don't fit the operation.
+
+func DotC(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, &ShapeError{`Dot`, vectorShape(a), vectorShape(b)}
+	}
+
+	return Dot(a, b), nil
+}
+
+func AggC(a, b []float64, op BOP) ([]float64, error) {
+	if len(a) != len(b) {
+		return nil, &ShapeError{`Agg`, vectorShape(a), vectorShape(b)}
+	}
+
+	return Agg(a, b, op), nil
+}
+
+func MAggC(a, b Matrix, op BOP) (Matrix, error) {
+	if e := sameShape(`MAgg`, a, b); e != nil {
+		return nil, e
+	}
+
+	return MAgg(a, b, op), nil
+}
+
+func MAggDC(a, b Matrix, op BOP) (Matrix, error) {
+	if e := sameShape(`MAggD`, a, b); e != nil {
+		return nil, e
+	}
+
+	return MAggD(a, b, op), nil
+}
+
+// the columns of a must be the length of b
+func MVDotC(a Matrix, b []float64) ([]float64, error) {
+	if _, cols := Dims(a); cols != len(b) {
+		return nil, &ShapeError{`MVDot`, a.Shape(), vectorShape(b)}
+	}
+
+	return MVDot(a, b), nil
+}
+
+// the columns of a must be the rows of b
+func MMDotC(a, b Matrix) (Matrix, error) {
+	_, aCols := Dims(a)
+
+	if bRows, _ := Dims(b); aCols != bRows {
+		return nil, &ShapeError{`MMDot`, a.Shape(), b.Shape()}
+	}
+
+	return MMDot(a, b), nil
+}
+
+// a and b must have the same number of columns
+func MOuterColAvgC(a, b Matrix) (Matrix, error) {
+	_, aCols := Dims(a)
+
+	if _, bCols := Dims(b); aCols != bCols {
+		return nil, &ShapeError{`MOuterColAvg`, a.Shape(), b.Shape()}
+	}
+
+	return MOuterColAvg(a, b), nil
+}
+
+// OuterIntoC is OuterInto where dst must be len(a) x len(b)
+func OuterIntoC(dst Matrix, a, b []float64) (Matrix, error) {
+	if rows, cols := Dims(dst); rows != len(a) || cols != len(b) {
+		return nil, &ShapeError{`Outer`, dst.Shape(), []int{len(a), len(b)}}
+	}
+
+	return OuterInto(dst, a, b), nil
+}
+
+func sameShape(op string, a, b Matrix) error {
+	aRows, aCols := Dims(a)
+
+	if bRows, bCols := Dims(b); aRows != bRows || aCols != bCols {
+		return &ShapeError{op, a.Shape(), b.Shape()}
+	}
+
+	return nil
+}
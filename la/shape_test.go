@@ -0,0 +1,61 @@
+package la_test
+
+import (
+	"errors"
+
+	. "github.com/hayden-erickson/neural-network/la"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checked", func() {
+	a := RandMatrix(2, 3)
+	b := RandMatrix(3, 4)
+
+	expectShapeError := func(e error, op string, x, y []int) {
+		var se *ShapeError
+
+		ExpectWithOffset(1, errors.Is(e, ErrShapeMismatch)).To(BeTrue())
+		ExpectWithOffset(1, errors.As(e, &se)).To(BeTrue())
+		ExpectWithOffset(1, se).To(Equal(&ShapeError{Op: op, A: x, B: y}))
+	}
+
+	It("computes the operation for compatible shapes", func() {
+		Expect(MMDotC(a, b)).To(Equal(MMDot(a, b)))
+		Expect(MVDotC(a, []float64{1, 2, 3})).To(Equal(MVDot(a, []float64{1, 2, 3})))
+		Expect(MAggC(a, a, SUM)).To(Equal(MAgg(a, a, SUM)))
+		Expect(MAggDC(a, a.T().T(), SUM)).To(Equal(MAggD(a, a, SUM)))
+		Expect(MOuterColAvgC(b, b)).To(Equal(MOuterColAvg(b, b)))
+		Expect(AggC([]float64{1, 2}, []float64{3, 4}, SUM)).To(Equal([]float64{4, 6}))
+		Expect(DotC([]float64{1, 2}, []float64{3, 4})).To(Equal(11.0))
+		Expect(OuterIntoC(ZeroMatrix(2, 1), []float64{1, 2}, []float64{3})).
+			To(Equal(Outer([]float64{1, 2}, []float64{3})))
+	})
+
+	It("returns the shapes and operation when they don't fit", func() {
+		_, e := MMDotC(b, a)
+		expectShapeError(e, `MMDot`, []int{3, 4}, []int{2, 3})
+		Expect(e.Error()).To(Equal(`MMDot: incompatible shapes [3 4] and [2 3]`))
+
+		_, e = MVDotC(a, []float64{1, 2})
+		expectShapeError(e, `MVDot`, []int{2, 3}, []int{2})
+
+		_, e = MAggC(a, a.T(), SUM)
+		expectShapeError(e, `MAgg`, []int{2, 3}, []int{3, 2})
+
+		_, e = MAggDC(a, b, SUM)
+		expectShapeError(e, `MAggD`, []int{2, 3}, []int{3, 4})
+
+		_, e = MOuterColAvgC(a, b)
+		expectShapeError(e, `MOuterColAvg`, []int{2, 3}, []int{3, 4})
+
+		_, e = AggC([]float64{1}, []float64{1, 2}, SUM)
+		expectShapeError(e, `Agg`, []int{1}, []int{2})
+
+		_, e = DotC([]float64{1, 2, 3}, []float64{1, 2})
+		expectShapeError(e, `Dot`, []int{3}, []int{2})
+
+		_, e = OuterIntoC(ZeroMatrix(2, 2), []float64{1, 2}, []float64{3})
+		expectShapeError(e, `Outer`, []int{2, 2}, []int{2, 1})
+	})
+})
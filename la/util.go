@@ -3,47 +3,112 @@ package la
 import (
 	"math"
 	"math/rand"
-	"time"
 )
 
-func gaussian() float64 {
-	val := rand.Float64()
+// The random functions draw from the global math/rand source, their
+// R variants from the given *rand.Rand so a run can be reproduced by
+// seeding it. A nil *rand.Rand also means the global source.
 
-	if rand.Intn(2) == 1 {
+func float64From(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+
+	return r.Float64()
+}
+
+func intnFrom(r *rand.Rand, n int) int {
+	if r == nil {
+		return rand.Intn(n)
+	}
+
+	return r.Intn(n)
+}
+
+func normFrom(r *rand.Rand) float64 {
+	if r == nil {
+		return rand.NormFloat64()
+	}
+
+	return r.NormFloat64()
+}
+
+// uniform is a value drawn uniformly from [-1, 1]
+func uniform(r *rand.Rand) float64 {
+	val := float64From(r)
+
+	if intnFrom(r, 2) == 1 {
 		val = -val
 	}
 
 	return val
 }
 
+// RandVector draws n values uniformly from [-1, 1]
 func RandVector(n int) []float64 {
-	rand.Seed(time.Now().UnixNano())
+	return RandVectorR(nil, n)
+}
 
-	var v []float64
+func RandVectorR(r *rand.Rand, n int) []float64 {
+	v := make([]float64, n)
 
-	for i := 0; i < n; i++ {
-		v = append(v, gaussian())
+	for i := range v {
+		v[i] = uniform(r)
+	}
+
+	return v
+}
+
+// UniformVector draws n values uniformly from [-limit, limit)
+func UniformVector(n int, limit float64) []float64 {
+	return UniformVectorR(nil, n, limit)
+}
+
+func UniformVectorR(r *rand.Rand, n int, limit float64) []float64 {
+	v := make([]float64, n)
+
+	for i := range v {
+		v[i] = limit * (2*float64From(r) - 1)
+	}
+
+	return v
+}
+
+// NormalVector draws n values from a normal distribution with
+// mean 0 and standard deviation std. The samples come from the
+// ziggurat method of rand.NormFloat64.
+func NormalVector(n int, std float64) []float64 {
+	return NormalVectorR(nil, n, std)
+}
+
+func NormalVectorR(r *rand.Rand, n int, std float64) []float64 {
+	v := make([]float64, n)
+
+	for i := range v {
+		v[i] = std * normFrom(r)
 	}
 
 	return v
 }
 
 func RandMatrixSquashed(n, m int) Matrix {
-	mat := RandMatrix(n, m)
+	return RandMatrixSquashedR(nil, n, m)
+}
+
+func RandMatrixSquashedR(r *rand.Rand, n, m int) Matrix {
+	mat := RandMatrixR(r, n, m)
 	return MSCALE(mat, 1/math.Sqrt(float64(m)))
 }
 
 func RandMatrix(n, m int) Matrix {
-	var d []float64
-
-	for i := 0; i < n; i++ {
-		d = append(d, RandVector(m)...)
-	}
+	return RandMatrixR(nil, n, m)
+}
 
+func RandMatrixR(r *rand.Rand, n, m int) Matrix {
 	return matrix{
 		x:    n,
 		y:    m,
-		data: d,
+		data: RandVectorR(r, n*m),
 	}
 }
 
@@ -63,6 +128,10 @@ func NewColMatrix(n, m int, d []float64) Matrix {
 	}
 }
 
+// NewMatrix copies data into a matrix with data[i][j] at row i and
+// column j, stored column major if colmaj is set. Before the layout
+// contract a column major matrix read each data[i] as its column i
+// instead, NewColMatrix over the concatenated rows still builds one.
 func NewMatrix(data [][]float64, colmaj bool) Matrix {
 	var n, m int
 	n = len(data)
@@ -70,20 +139,26 @@ func NewMatrix(data [][]float64, colmaj bool) Matrix {
 
 	d := make([]float64, n*m)
 
-	for i := 0; i < n; i++ {
-		for j := 0; j < m; j++ {
-			d[i*m+j] = data[i][j]
+	if colmaj {
+		for i := 0; i < n; i++ {
+			for j := 0; j < m; j++ {
+				d[j*n+i] = data[i][j]
+			}
 		}
-	}
 
-	if colmaj {
 		return colmajmatrix{
-			x:    m,
-			y:    n,
+			x:    n,
+			y:    m,
 			data: d,
 		}
 	}
 
+	for i := 0; i < n; i++ {
+		for j := 0; j < m; j++ {
+			d[i*m+j] = data[i][j]
+		}
+	}
+
 	return matrix{
 		x:    n,
 		y:    m,
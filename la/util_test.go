@@ -0,0 +1,42 @@
+package la_test
+
+import (
+	"math/rand"
+
+	. "github.com/hayden-erickson/neural-network/la"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Random", func() {
+	seeded := func() *rand.Rand {
+		return rand.New(rand.NewSource(42))
+	}
+
+	It("draws the same values from sources with the same seed", func() {
+		Expect(RandVectorR(seeded(), 50)).To(Equal(RandVectorR(seeded(), 50)))
+		Expect(RandMatrixR(seeded(), 5, 7)).To(Equal(RandMatrixR(seeded(), 5, 7)))
+		Expect(RandMatrixSquashedR(seeded(), 5, 7)).To(Equal(RandMatrixSquashedR(seeded(), 5, 7)))
+		Expect(UniformVectorR(seeded(), 50, 2)).To(Equal(UniformVectorR(seeded(), 50, 2)))
+		Expect(NormalVectorR(seeded(), 50, 2)).To(Equal(NormalVectorR(seeded(), 50, 2)))
+	})
+
+	It("continues the sequence of a source", func() {
+		r := seeded()
+		first := RandVectorR(r, 50)
+		second := RandVectorR(r, 50)
+
+		Expect(second).ToNot(Equal(first))
+
+		// the rows of a matrix are drawn one after the other
+		m := RandMatrixR(seeded(), 2, 50)
+		Expect(m.Row(0)).To(Equal(first))
+		Expect(m.Row(1)).To(Equal(second))
+	})
+
+	It("never repeats vectors created back to back", func() {
+		for i := 0; i < 100; i++ {
+			Expect(RandVector(10)).ToNot(Equal(RandVector(10)))
+		}
+	})
+})
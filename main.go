@@ -1,60 +1,132 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
 	"strconv"
 
 	"github.com/hayden-erickson/neural-network/loaders"
 	"github.com/hayden-erickson/neural-network/nn"
 )
 
+const networkFile = `./network.nn`
+
+var gradCheck = flag.Bool(`gradcheck`, false, `compare the back propagated gradients to finite differences and exit`)
+var seed = flag.Int64(`seed`, 1, `seeds every random choice so a run can be repeated`)
+
 func main() {
+	flag.Parse()
+
+	if *gradCheck {
+		runGradCheck()
+		return
+	}
+
 	// benchmarkParFor()
 	runSGD()
 }
 
+// runGradCheck checks the gradients of a small network with a hidden
+// layer on a few test examples, every parameter costs two passes
+func runGradCheck() {
+	_, testData := getData()
+
+	inputSize, outputSize := len(testData[0].GetInput()), len(testData[0].GetOutput())
+
+	net, e := nn.NewNetworkInit(rand.New(rand.NewSource(*seed)), []int{inputSize, 16, outputSize})
+
+	if e != nil {
+		panic(e)
+	}
+
+	net.Activations = []nn.Differentiable{nn.Sigmoid, nn.Softmax}
+
+	result := nn.GradCheck(net, testData[:2], nn.Sigmoid, nn.CategoricalCrossEntropy)
+
+	fmt.Println("# layer\tbackprop w\tbackprop b\tmbackprop w\tmbackprop b")
+
+	for i := range result.BackProp {
+		fmt.Printf("%d\t%e\t%e\t%e\t%e\n", i,
+			result.BackProp[i].Weights, result.BackProp[i].Biases,
+			result.MBackProp[i].Weights, result.MBackProp[i].Biases)
+	}
+}
+
 func runSGD() {
 
 	trainingData, testData := getData()
 
 	inputSize, outputSize := len(testData[0].GetInput()), len(testData[0].GetOutput())
 
-	net, e := nn.NewNetwork([]int{inputSize, outputSize})
+	r := rand.New(rand.NewSource(*seed))
+	net, e := nn.NewNetworkInit(r, []int{inputSize, outputSize})
 
 	if e != nil {
 		panic(e)
 	}
 
+	// classify the digits with a softmax over the output layer
+	net.Activations = []nn.Differentiable{nn.Softmax}
+
+	// halve the learning rate when the validation cost stalls for 3 epochs
+	plateau := nn.NewReduceOnPlateau(0.5, 3)
+	// give up once it stalls for 6 keeping the best network
+	stopping := nn.NewEarlyStopping(6, 0)
+	stopping.RestoreBest = true
+
 	sgd := nn.SGD{
 		Activation: nn.Sigmoid,
-		Cost:       nn.CrossEntropy,
+		Cost:       nn.CategoricalCrossEntropy,
 		Eta:        5,
 		Net:        net,
+		Seed:       r.Int63(),
+		Schedule:   plateau,
+		// hold out a validation set the same size as the test set
+		Evaluation: &nn.Evaluation{Split: 1.0 / 6, Matcher: loaders.MnistMatcher},
+		Workers:    runtime.NumCPU(),
 	}
 
-	fmt.Printf("# epoch\taccuracy/%d\tcost\n", len(testData))
+	fmt.Println("# epoch\tvalidation accuracy\tcost\ttime")
+
+	sgd.Callbacks = []nn.Callback{stopping, nn.CallbackFuncs{
+		OnEpochEnd: func(s *nn.TrainingState) {
+			fmt.Printf("%d\t%d/%d\t\t%f\t%s\n", s.Epoch+1, s.Correct, s.Total, s.Cost, s.EpochTime)
+		},
+	}}
 
-	eFactor := 1
+	if e := sgd.MRun(trainingData, 30, 10); e != nil {
+		panic(e)
+	}
 
 	numCorrect, cost := nn.Evaluate(sgd, testData, loaders.MnistMatcher)
-	fmt.Printf("%d\t%d\t\t%f\n", 0, numCorrect, cost)
+	fmt.Printf("# test accuracy %d/%d cost %f (best epoch %d)\n", numCorrect, len(testData), cost, stopping.BestEpoch+1)
+	fmt.Print(nn.EvaluateReport(sgd, testData, 3))
 
-	// var oW []la.Matrix
-	// var oB [][]float64
+	predictions := nn.EvaluatePredictions(sgd, testData)
+	auc, digits := 0.0, 0
 
-	for i := 0; i < 30; i++ {
-		// copy(oW, sgd.Net.Weights)
-		// copy(oB, sgd.Net.Biases)
+	// digits missing from the test data have no roc curve
+	for digit := 0; digit < outputSize; digit++ {
+		if a := predictions.ROC(digit).AUC(); !math.IsNaN(a) {
+			auc += a
+			digits++
+		}
+	}
 
-		sgd.MRun(trainingData, eFactor, 10)
-		// sgd.Run(trainingData[:N], eFactor, 10)
+	ece, e := predictions.ECE(10)
 
-		// if reflect.DeepEqual(oW, sgd.Net.Weights) {
-		// 	panic(`weights did not change`)
-		// }
+	if e != nil {
+		panic(e)
+	}
 
-		numCorrect, cost := nn.Evaluate(sgd, testData, loaders.MnistMatcher)
-		fmt.Printf("%d\t%d\t\t%f\n", eFactor*(i+1), numCorrect, cost)
+	fmt.Printf("# log loss %f brier %f ece %f mean roc auc %f\n",
+		predictions.LogLoss(), predictions.Brier(), ece, auc/float64(digits))
+
+	if e := nn.SaveFile(networkFile, sgd.Net, sgd.Activation, sgd.Cost); e != nil {
+		panic(e)
 	}
 }
 
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+var ErrBins = errors.New(`There must be at least one bin`)
+
+// Bin holds the examples whose largest output, the confidence of the
+// prediction, is in [Lower, Upper). Confidence is their average largest
+// output and Accuracy the fraction of them predicted correctly.
+type Bin struct {
+	Lower      float64
+	Upper      float64
+	Count      int
+	Confidence float64
+	Accuracy   float64
+}
+
+// Reliability is the data of a reliability diagram, a well calibrated
+// network is as accurate in every bin as it is confident
+type Reliability []Bin
+
+// Reliability splits [0, 1] into bins of equal width and puts every
+// example in the bin of its confidence, a confidence of 1 in the last.
+// Examples with a NaN or infinite confidence are left out.
+func (p *Predictions) Reliability(bins int) (Reliability, error) {
+	if bins < 1 {
+		return nil, ErrBins
+	}
+
+	out := make(Reliability, bins)
+	correct := make([]int, bins)
+
+	for i := range out {
+		out[i].Lower = float64(i) / float64(bins)
+		out[i].Upper = float64(i+1) / float64(bins)
+	}
+
+	for i, output := range p.Outputs {
+		predicted := ArgMax(output)
+		confidence := output[predicted]
+
+		if math.IsNaN(confidence) || math.IsInf(confidence, 0) {
+			continue
+		}
+
+		b := int(math.Min(math.Max(confidence*float64(bins), 0), float64(bins-1)))
+
+		out[b].Count++
+		out[b].Confidence += confidence
+
+		if predicted == ArgMax(p.Desired[i]) {
+			correct[b]++
+		}
+	}
+
+	for i := range out {
+		out[i].Confidence = mean(out[i].Confidence, out[i].Count)
+		out[i].Accuracy = ratio(correct[i], out[i].Count)
+	}
+
+	return out, nil
+}
+
+// ECE is the expected calibration error, the difference between the
+// accuracy and confidence of every bin weighted by its examples
+func (r Reliability) ECE() float64 {
+	total, n := 0.0, 0
+
+	for _, b := range r {
+		total += float64(b.Count) * math.Abs(b.Accuracy-b.Confidence)
+		n += b.Count
+	}
+
+	return mean(total, n)
+}
+
+// ECE is the expected calibration error over bins of equal width
+func (p *Predictions) ECE(bins int) (float64, error) {
+	r, e := p.Reliability(bins)
+
+	if e != nil {
+		return 0, e
+	}
+
+	return r.ECE(), nil
+}
+
+// WriteCSV writes the bins a row each under a header naming the fields
+func (r Reliability) WriteCSV(w io.Writer) error {
+	rows := [][]float64{}
+
+	for _, b := range r {
+		rows = append(rows, []float64{b.Lower, b.Upper, float64(b.Count), b.Confidence, b.Accuracy})
+	}
+
+	return writeCSV(w, []string{`lower`, `upper`, `count`, `confidence`, `accuracy`}, rows)
+}
@@ -0,0 +1,195 @@
+package metrics
+
+// ConfusionMatrix counts how examples of each class were classified,
+// Counts[actual][predicted]
+type ConfusionMatrix struct {
+	Counts [][]int
+}
+
+func NewConfusionMatrix(classes int) *ConfusionMatrix {
+	counts := make([][]int, classes)
+
+	for i := range counts {
+		counts[i] = make([]int, classes)
+	}
+
+	return &ConfusionMatrix{Counts: counts}
+}
+
+// ArgMax is the index of the largest value of v, the class a
+// network's output or a one hot desired output stands for
+func ArgMax(v []float64) int {
+	max := 0
+
+	for i, x := range v {
+		if x > v[max] {
+			max = i
+		}
+	}
+
+	return max
+}
+
+func (c *ConfusionMatrix) Classes() int {
+	return len(c.Counts)
+}
+
+// Add counts the output of a network for an example with the desired
+// output, each standing for the class of its largest value
+func (c *ConfusionMatrix) Add(output, desired []float64) {
+	c.AddClass(ArgMax(desired), ArgMax(output))
+}
+
+func (c *ConfusionMatrix) AddClass(actual, predicted int) {
+	c.Counts[actual][predicted]++
+}
+
+func (c *ConfusionMatrix) Total() int {
+	total := 0
+
+	for i := range c.Counts {
+		total += c.Support(i)
+	}
+
+	return total
+}
+
+// Correct is the number of examples predicted as their own class
+func (c *ConfusionMatrix) Correct() int {
+	correct := 0
+
+	for i := range c.Counts {
+		correct += c.Counts[i][i]
+	}
+
+	return correct
+}
+
+func (c *ConfusionMatrix) Accuracy() float64 {
+	return ratio(c.Correct(), c.Total())
+}
+
+// Support is the number of examples of the class
+func (c *ConfusionMatrix) Support(class int) int {
+	support := 0
+
+	for _, n := range c.Counts[class] {
+		support += n
+	}
+
+	return support
+}
+
+// Predicted is the number of examples predicted as the class
+func (c *ConfusionMatrix) Predicted(class int) int {
+	predicted := 0
+
+	for i := range c.Counts {
+		predicted += c.Counts[i][class]
+	}
+
+	return predicted
+}
+
+// Precision is the fraction of the examples predicted as the class
+// that are of the class, 0 if none were
+func (c *ConfusionMatrix) Precision(class int) float64 {
+	return ratio(c.Counts[class][class], c.Predicted(class))
+}
+
+// Recall is the fraction of the examples of the class that were
+// predicted as it, 0 if there are none
+func (c *ConfusionMatrix) Recall(class int) float64 {
+	return ratio(c.Counts[class][class], c.Support(class))
+}
+
+// F1 is the harmonic mean of the precision and recall of the class
+func (c *ConfusionMatrix) F1(class int) float64 {
+	return f1(c.Precision(class), c.Recall(class))
+}
+
+// Scores are the precision, recall and F1 of a class or an average
+// of them over every class
+type Scores struct {
+	Precision float64
+	Recall    float64
+	F1        float64
+}
+
+func (c *ConfusionMatrix) Class(class int) Scores {
+	return Scores{c.Precision(class), c.Recall(class), c.F1(class)}
+}
+
+// Average is how the scores of each class are combined
+type Average int
+
+const (
+	// Macro is the mean over classes, every class counting the same
+	Macro Average = iota
+	// Micro scores the counts summed over classes. With a single label
+	// per example every score is the accuracy.
+	Micro
+	// Weighted is the mean over classes weighted by their support
+	Weighted
+)
+
+func (a Average) String() string {
+	switch a {
+	case Macro:
+		return `macro avg`
+	case Micro:
+		return `micro avg`
+	case Weighted:
+		return `weighted avg`
+	}
+
+	return `unknown avg`
+}
+
+// Averaged combines the scores of every class
+func (c *ConfusionMatrix) Averaged(avg Average) Scores {
+	if avg == Micro {
+		// every false positive of one class is a false negative of another
+		accuracy := c.Accuracy()
+		return Scores{accuracy, accuracy, accuracy}
+	}
+
+	var out Scores
+	total := 0.0
+
+	for i := range c.Counts {
+		weight := 1.0
+
+		if avg == Weighted {
+			weight = float64(c.Support(i))
+		}
+
+		s := c.Class(i)
+		out.Precision += weight * s.Precision
+		out.Recall += weight * s.Recall
+		out.F1 += weight * s.F1
+		total += weight
+	}
+
+	if total == 0 {
+		return Scores{}
+	}
+
+	return Scores{out.Precision / total, out.Recall / total, out.F1 / total}
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+
+	return float64(a) / float64(b)
+}
+
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+
+	return 2 * precision * recall / (precision + recall)
+}
@@ -0,0 +1,75 @@
+package metrics_test
+
+import (
+	. "github.com/hayden-erickson/neural-network/metrics"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfusionMatrix", func() {
+	var c *ConfusionMatrix
+
+	BeforeEach(func() {
+		c = NewConfusionMatrix(3)
+
+		counts := [][]int{
+			{5, 1, 0},
+			{2, 3, 1},
+			{0, 0, 4},
+		}
+
+		for actual, row := range counts {
+			for predicted, n := range row {
+				for k := 0; k < n; k++ {
+					c.AddClass(actual, predicted)
+				}
+			}
+		}
+	})
+
+	expectScores := func(s Scores, precision, recall, f1 float64) {
+		ExpectWithOffset(1, s.Precision).To(BeNumerically(`~`, precision, 1e-12))
+		ExpectWithOffset(1, s.Recall).To(BeNumerically(`~`, recall, 1e-12))
+		ExpectWithOffset(1, s.F1).To(BeNumerically(`~`, f1, 1e-12))
+	}
+
+	It("counts the class of the largest output against the desired class", func() {
+		m := NewConfusionMatrix(3)
+		m.Add([]float64{0.1, 0.3, 0.2}, []float64{1, 0, 0})
+		m.Add([]float64{0.1, 0.3, 0.9}, []float64{0, 0, 1})
+
+		Expect(m.Counts).To(Equal([][]int{{0, 1, 0}, {0, 0, 0}, {0, 0, 1}}))
+		Expect(ArgMax([]float64{-2, -1, -3})).To(Equal(1))
+	})
+
+	It("computes the totals", func() {
+		Expect(c.Total()).To(Equal(16))
+		Expect(c.Correct()).To(Equal(12))
+		Expect(c.Accuracy()).To(Equal(0.75))
+		Expect([]int{c.Support(0), c.Support(1), c.Support(2)}).To(Equal([]int{6, 6, 4}))
+		Expect([]int{c.Predicted(0), c.Predicted(1), c.Predicted(2)}).To(Equal([]int{7, 4, 5}))
+	})
+
+	It("scores every class", func() {
+		expectScores(c.Class(0), 5.0/7, 5.0/6, 10.0/13)
+		expectScores(c.Class(1), 0.75, 0.5, 0.6)
+		expectScores(c.Class(2), 0.8, 1, 8.0/9)
+	})
+
+	It("averages the scores of the classes", func() {
+		expectScores(c.Averaged(Macro),
+			(5.0/7+0.75+0.8)/3, (5.0/6+0.5+1)/3, (10.0/13+0.6+8.0/9)/3)
+		expectScores(c.Averaged(Weighted),
+			(6*5.0/7+6*0.75+4*0.8)/16, (6*5.0/6+6*0.5+4)/16, (6*10.0/13+6*0.6+4*8.0/9)/16)
+		expectScores(c.Averaged(Micro), 0.75, 0.75, 0.75)
+	})
+
+	It("scores 0 instead of dividing by 0", func() {
+		m := NewConfusionMatrix(2)
+		m.AddClass(0, 0)
+
+		expectScores(m.Class(1), 0, 0, 0)
+		expectScores(NewConfusionMatrix(2).Averaged(Weighted), 0, 0, 0)
+		Expect(NewConfusionMatrix(2).Accuracy()).To(Equal(0.0))
+	})
+})
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Point is where a Curve is at a threshold on the scores
+type Point struct {
+	Threshold float64
+	X         float64
+	Y         float64
+}
+
+// Curve is a series of points from the highest threshold down,
+// XName and YName naming its axes
+type Curve struct {
+	XName  string
+	YName  string
+	Points []Point
+}
+
+// AUC is the area under the curve by the trapezoidal rule, NaN if the
+// curve is undefined or has no points past its start
+func (c Curve) AUC() float64 {
+	if len(c.Points) < 2 {
+		return math.NaN()
+	}
+
+	area := 0.0
+
+	for i := 1; i < len(c.Points); i++ {
+		a, b := c.Points[i-1], c.Points[i]
+		area += (b.X - a.X) * (a.Y + b.Y) / 2
+	}
+
+	return area
+}
+
+// WriteCSV writes the points a row each under a header naming the axes
+func (c Curve) WriteCSV(w io.Writer) error {
+	rows := [][]float64{}
+
+	for _, p := range c.Points {
+		rows = append(rows, []float64{p.Threshold, p.X, p.Y})
+	}
+
+	return writeCSV(w, []string{`threshold`, c.XName, c.YName}, rows)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]float64) error {
+	cw := csv.NewWriter(w)
+
+	if e := cw.Write(header); e != nil {
+		return e
+	}
+
+	record := make([]string, len(header))
+
+	for _, row := range rows {
+		for i, x := range row {
+			record[i] = strconv.FormatFloat(x, 'g', -1, 64)
+		}
+
+		if e := cw.Write(record); e != nil {
+			return e
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Predictions keep the outputs of a network with the desired outputs
+// to judge the scores themselves rather than only their largest value.
+// The outputs are read as the probability of each class, as given by
+// a softmax or sigmoid output layer.
+type Predictions struct {
+	Outputs [][]float64
+	Desired [][]float64
+}
+
+// Add keeps copies of the output of a network for an example and the
+// desired output
+func (p *Predictions) Add(output, desired []float64) {
+	p.Outputs = append(p.Outputs, append([]float64(nil), output...))
+	p.Desired = append(p.Desired, append([]float64(nil), desired...))
+}
+
+// epsilon keeps log loss finite for outputs of exactly 0
+const epsilon = 1e-15
+
+// LogLoss is the average cross entropy of the desired classes,
+// -sum(y log(p)) over the outputs of every example
+func (p *Predictions) LogLoss() float64 {
+	total := 0.0
+
+	for i, output := range p.Outputs {
+		for k, y := range p.Desired[i] {
+			if y != 0 {
+				total -= y * math.Log(math.Min(math.Max(output[k], epsilon), 1))
+			}
+		}
+	}
+
+	return mean(total, len(p.Outputs))
+}
+
+// Brier is the average squared distance between the outputs and the
+// desired outputs, summed over the classes
+func (p *Predictions) Brier() float64 {
+	total := 0.0
+
+	for i, output := range p.Outputs {
+		for k, y := range p.Desired[i] {
+			total += (output[k] - y) * (output[k] - y)
+		}
+	}
+
+	return mean(total, len(p.Outputs))
+}
+
+// scored is the score of one example for a class and
+// whether the example is of the class
+type scored struct {
+	score    float64
+	positive bool
+}
+
+// oneVsRest scores every example for class, highest first
+func (p *Predictions) oneVsRest(class int) (out []scored, positives int) {
+	out = make([]scored, len(p.Outputs))
+
+	for i, output := range p.Outputs {
+		out[i] = scored{output[class], ArgMax(p.Desired[i]) == class}
+
+		if out[i].positive {
+			positives++
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].score > out[j].score
+	})
+
+	return out, positives
+}
+
+// threshold is a cut off for the score of a class and
+// the true and false positives scoring at least as much
+type threshold struct {
+	score  float64
+	tp, fp int
+}
+
+// sweep lowers the threshold for class through every distinct score
+func (p *Predictions) sweep(class int) (out []threshold, positives, negatives int) {
+	ss, positives := p.oneVsRest(class)
+	tp, fp := 0, 0
+
+	for i, s := range ss {
+		if s.positive {
+			tp++
+		} else {
+			fp++
+		}
+
+		// examples scoring the same can't be told apart by a threshold
+		if i == len(ss)-1 || ss[i+1].score != s.score {
+			out = append(out, threshold{s.score, tp, fp})
+		}
+	}
+
+	return out, positives, len(ss) - positives
+}
+
+// ROC is the receiver operating characteristic of class against the
+// rest, the false positive rate (X) and true positive rate (Y) of
+// every threshold from the highest score down. A rate is NaN when the
+// class has no examples or there are none of the rest.
+func (p *Predictions) ROC(class int) Curve {
+	ts, positives, negatives := p.sweep(class)
+	c := Curve{XName: `fpr`, YName: `tpr`, Points: []Point{{math.Inf(1), 0, 0}}}
+
+	for _, t := range ts {
+		c.Points = append(c.Points, Point{t.score, rate(t.fp, negatives), rate(t.tp, positives)})
+	}
+
+	return c
+}
+
+// PrecisionRecall is the recall (X) and precision (Y) of class against
+// the rest at every threshold from the highest score down, starting
+// from a precision of 1 at a recall of 0. The recall is NaN when the
+// class has no examples.
+func (p *Predictions) PrecisionRecall(class int) Curve {
+	ts, positives, _ := p.sweep(class)
+	c := Curve{XName: `recall`, YName: `precision`, Points: []Point{{math.Inf(1), 0, 1}}}
+
+	for _, t := range ts {
+		c.Points = append(c.Points, Point{t.score, rate(t.tp, positives), ratio(t.tp, t.tp+t.fp)})
+	}
+
+	return c
+}
+
+// rate is the fraction of the total, undefined without any
+func rate(n, total int) float64 {
+	if total == 0 {
+		return math.NaN()
+	}
+
+	return float64(n) / float64(total)
+}
+
+func mean(total float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+
+	return total / float64(n)
+}
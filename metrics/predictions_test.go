@@ -0,0 +1,162 @@
+package metrics_test
+
+import (
+	"bytes"
+	"math"
+
+	. "github.com/hayden-erickson/neural-network/metrics"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Predictions", func() {
+	var p *Predictions
+
+	BeforeEach(func() {
+		p = &Predictions{}
+
+		// the score of class 0 and whether the example is of it
+		for _, e := range []struct {
+			score    float64
+			positive bool
+		}{{0.9, true}, {0.8, true}, {0.7, false}, {0.6, true}, {0.4, true}, {0.4, false}, {0.2, false}} {
+			desired := []float64{0, 1}
+
+			if e.positive {
+				desired = []float64{1, 0}
+			}
+
+			p.Add([]float64{e.score, 1 - e.score}, desired)
+		}
+	})
+
+	expectPoints := func(c Curve, xs, ys []float64) {
+		ExpectWithOffset(1, c.Points).To(HaveLen(len(xs)))
+
+		for i, point := range c.Points {
+			ExpectWithOffset(1, point.X).To(BeNumerically(`~`, xs[i], 1e-12))
+			ExpectWithOffset(1, point.Y).To(BeNumerically(`~`, ys[i], 1e-12))
+		}
+	}
+
+	It("keeps copies of the outputs", func() {
+		output := []float64{0.5, 0.5}
+		p.Add(output, []float64{1, 0})
+		output[0] = 1
+
+		Expect(p.Outputs[7]).To(Equal([]float64{0.5, 0.5}))
+	})
+
+	It("traces the ROC curve of a class against the rest", func() {
+		roc := p.ROC(0)
+
+		Expect(roc.Points[0].Threshold).To(Equal(math.Inf(1)))
+		Expect(roc.Points[5].Threshold).To(Equal(0.4))
+		expectPoints(roc,
+			[]float64{0, 0, 0, 1.0 / 3, 1.0 / 3, 2.0 / 3, 1},
+			[]float64{0, 0.25, 0.5, 0.5, 0.75, 1, 1})
+
+		// the chance a positive outscores a negative, ties counting half
+		Expect(roc.AUC()).To(BeNumerically(`~`, 9.5/12, 1e-12))
+	})
+
+	It("traces the precision recall curve of a class against the rest", func() {
+		pr := p.PrecisionRecall(0)
+
+		expectPoints(pr,
+			[]float64{0, 0.25, 0.5, 0.5, 0.75, 1, 1},
+			[]float64{1, 1, 1, 2.0 / 3, 0.75, 4.0 / 6, 4.0 / 7})
+		Expect(pr.AUC()).To(BeNumerically(`~`, 0.5+0.25*(2.0/3+0.75)/2+0.25*(0.75+4.0/6)/2, 1e-12))
+	})
+
+	It("computes the log loss and Brier score", func() {
+		// the outputs of the desired classes
+		desired := []float64{0.9, 0.8, 0.3, 0.6, 0.4, 0.6, 0.8}
+		logLoss, brier := 0.0, 0.0
+
+		for _, x := range desired {
+			logLoss -= math.Log(x) / 7
+			brier += 2 * (1 - x) * (1 - x) / 7
+		}
+
+		Expect(p.LogLoss()).To(BeNumerically(`~`, logLoss, 1e-12))
+		Expect(p.Brier()).To(BeNumerically(`~`, brier, 1e-12))
+
+		p.Add([]float64{0, 1}, []float64{1, 0})
+		Expect(math.IsInf(p.LogLoss(), 0)).To(BeFalse())
+	})
+
+	It("bins the examples by confidence", func() {
+		r, err := p.Reliability(5)
+
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(r).To(HaveLen(5))
+		Expect([]int{r[0].Count, r[1].Count, r[2].Count}).To(Equal([]int{0, 0, 0}))
+		Expect([]float64{r[3].Lower, r[3].Upper}).To(Equal([]float64{0.6, 0.8}))
+		Expect(r[3].Count).To(Equal(4))
+		Expect(r[3].Confidence).To(BeNumerically(`~`, 0.625, 1e-12))
+		Expect(r[3].Accuracy).To(Equal(0.5))
+		Expect(r[4].Count).To(Equal(3))
+		Expect(r[4].Confidence).To(BeNumerically(`~`, 2.5/3, 1e-12))
+		Expect(r[4].Accuracy).To(Equal(1.0))
+
+		ece, err := p.ECE(5)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ece).To(BeNumerically(`~`, 1.0/7, 1e-12))
+
+		p.Add([]float64{1, 0}, []float64{1, 0})
+		r, _ = p.Reliability(5)
+		Expect(r[4].Count).To(Equal(4))
+	})
+
+	It("leaves examples without a finite confidence out of the bins", func() {
+		q := &Predictions{}
+		q.Add([]float64{math.NaN(), 0.2}, []float64{1, 0})
+		q.Add([]float64{0.1, math.Inf(1)}, []float64{0, 1})
+		q.Add([]float64{0.9, 0.1}, []float64{1, 0})
+
+		r, err := q.Reliability(2)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect([]int{r[0].Count, r[1].Count}).To(Equal([]int{0, 1}))
+		Expect(r[1].Confidence).To(Equal(0.9))
+		Expect(r.ECE()).To(BeNumerically(`~`, 0.1, 1e-12))
+	})
+
+	It("rejects fewer than one bin", func() {
+		for _, bins := range []int{0, -1} {
+			_, err := p.Reliability(bins)
+			Expect(err).To(Equal(ErrBins))
+
+			_, err = p.ECE(bins)
+			Expect(err).To(Equal(ErrBins))
+		}
+	})
+
+	It("leaves the curves of a class without examples undefined", func() {
+		// every example is of class 0, none of class 1
+		q := &Predictions{}
+		q.Add([]float64{0.7, 0.3}, []float64{1, 0})
+		q.Add([]float64{0.4, 0.6}, []float64{1, 0})
+
+		Expect(math.IsNaN(q.ROC(0).AUC())).To(BeTrue())
+		Expect(math.IsNaN(q.ROC(1).AUC())).To(BeTrue())
+		Expect(math.IsNaN(q.PrecisionRecall(1).AUC())).To(BeTrue())
+		Expect(q.PrecisionRecall(0).AUC()).To(Equal(1.0))
+		Expect(math.IsNaN((&Predictions{}).ROC(0).AUC())).To(BeTrue())
+	})
+
+	It("writes curves and bins as CSV", func() {
+		buf := &bytes.Buffer{}
+		c := Curve{XName: `fpr`, YName: `tpr`, Points: []Point{{math.Inf(1), 0, 0}, {0.5, 0.25, 1}}}
+
+		Expect(c.WriteCSV(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("threshold,fpr,tpr\n+Inf,0,0\n0.5,0.25,1\n"))
+
+		buf.Reset()
+		Expect(Reliability{{0, 0.5, 2, 0.25, 0.5}}.WriteCSV(buf)).To(Succeed())
+		Expect(buf.String()).To(Equal("lower,upper,count,confidence,accuracy\n0,0.5,2,0.25,0.5\n"))
+	})
+})
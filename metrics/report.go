@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Report collects every metric of a classifier over the examples
+// added to it
+type Report struct {
+	Confusion *ConfusionMatrix
+	TopK      []*TopK
+	// Labels name the classes in String, their index if nil
+	Labels []string
+}
+
+// NewReport creates a report of classes classes computing the top k
+// accuracy for each of ks
+func NewReport(classes int, ks ...int) *Report {
+	r := &Report{Confusion: NewConfusionMatrix(classes)}
+
+	for _, k := range ks {
+		r.TopK = append(r.TopK, &TopK{K: k})
+	}
+
+	return r
+}
+
+// Add counts the output of a network for an example with the desired
+// output
+func (r *Report) Add(output, desired []float64) {
+	r.Confusion.Add(output, desired)
+
+	for _, t := range r.TopK {
+		t.Add(output, desired)
+	}
+}
+
+func (r *Report) label(class int) string {
+	if class < len(r.Labels) {
+		return r.Labels[class]
+	}
+
+	return strconv.Itoa(class)
+}
+
+// String lays out the scores of every class and their averages, the
+// accuracies and the confusion matrix as a table
+func (r *Report) String() string {
+	c := r.Confusion
+	b := &strings.Builder{}
+
+	width := len(Weighted.String())
+
+	for i := 0; i < c.Classes(); i++ {
+		if l := len(r.label(i)); l > width {
+			width = l
+		}
+	}
+
+	row := func(name string, s Scores, support int) {
+		fmt.Fprintf(b, "%*s %10.4f %10.4f %10.4f %10d\n", width, name, s.Precision, s.Recall, s.F1, support)
+	}
+
+	fmt.Fprintf(b, "%*s %10s %10s %10s %10s\n\n", width, ``, `precision`, `recall`, `f1-score`, `support`)
+
+	for i := 0; i < c.Classes(); i++ {
+		row(r.label(i), c.Class(i), c.Support(i))
+	}
+
+	fmt.Fprintf(b, "\n%*s %32.4f %10d\n", width, `accuracy`, c.Accuracy(), c.Total())
+
+	for _, avg := range []Average{Macro, Weighted} {
+		row(avg.String(), c.Averaged(avg), c.Total())
+	}
+
+	for _, t := range r.TopK {
+		fmt.Fprintf(b, "%*s %32.4f %10d\n", width, fmt.Sprintf(`top %d`, t.K), t.Accuracy(), t.Total)
+	}
+
+	// rows are the actual classes, columns the predicted
+	cell := 6
+
+	for i := 0; i < c.Classes(); i++ {
+		if l := len(r.label(i)); l > cell {
+			cell = l
+		}
+	}
+
+	fmt.Fprintf(b, "\n%*s", width, `actual`)
+
+	for j := 0; j < c.Classes(); j++ {
+		fmt.Fprintf(b, " %*s", cell, r.label(j))
+	}
+
+	for i, counts := range c.Counts {
+		fmt.Fprintf(b, "\n%*s", width, r.label(i))
+
+		for _, n := range counts {
+			fmt.Fprintf(b, " %*d", cell, n)
+		}
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"strings"
+
+	. "github.com/hayden-erickson/neural-network/metrics"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	It("finds the class among the k largest outputs", func() {
+		output := []float64{0.1, 0.5, 0.3, 0.5}
+
+		Expect(InTopK(output, 1, 1)).To(BeTrue())
+		Expect(InTopK(output, 2, 2)).To(BeFalse())
+		Expect(InTopK(output, 2, 3)).To(BeTrue())
+		Expect(InTopK(output, 0, 4)).To(BeTrue())
+	})
+
+	It("accumulates the confusion matrix and top k accuracies", func() {
+		r := NewReport(3, 1, 2)
+
+		r.Add([]float64{0.6, 0.3, 0.1}, []float64{1, 0, 0})
+		r.Add([]float64{0.6, 0.3, 0.1}, []float64{0, 1, 0})
+		r.Add([]float64{0.6, 0.3, 0.1}, []float64{0, 0, 1})
+		r.Add([]float64{0.1, 0.3, 0.6}, []float64{0, 0, 1})
+
+		Expect(r.Confusion.Counts).To(Equal([][]int{{1, 0, 0}, {1, 0, 0}, {1, 0, 1}}))
+		Expect(r.TopK[0].Accuracy()).To(Equal(r.Confusion.Accuracy()))
+		Expect(*r.TopK[1]).To(Equal(TopK{K: 2, Correct: 3, Total: 4}))
+	})
+
+	It("lays out every metric as a table", func() {
+		r := NewReport(2, 2)
+		r.Labels = []string{`cat`, `dog`}
+
+		r.Add([]float64{1, 0}, []float64{1, 0})
+		r.Add([]float64{1, 0}, []float64{1, 0})
+		r.Add([]float64{1, 0}, []float64{0, 1})
+		r.Add([]float64{0, 1}, []float64{0, 1})
+
+		lines := strings.Split(r.String(), "\n")
+
+		Expect(strings.Fields(lines[0])).To(Equal([]string{`precision`, `recall`, `f1-score`, `support`}))
+		Expect(strings.Fields(lines[2])).To(Equal([]string{`cat`, `0.6667`, `1.0000`, `0.8000`, `2`}))
+		Expect(strings.Fields(lines[3])).To(Equal([]string{`dog`, `1.0000`, `0.5000`, `0.6667`, `2`}))
+		Expect(strings.Fields(lines[5])).To(Equal([]string{`accuracy`, `0.7500`, `4`}))
+		Expect(lines[6]).To(HavePrefix(`   macro avg`))
+		Expect(lines[7]).To(HavePrefix(`weighted avg`))
+		Expect(strings.Fields(lines[8])).To(Equal([]string{`top`, `2`, `1.0000`, `4`}))
+		Expect(strings.Fields(lines[10])).To(Equal([]string{`actual`, `cat`, `dog`}))
+		Expect(strings.Fields(lines[11])).To(Equal([]string{`cat`, `2`, `0`}))
+		Expect(strings.Fields(lines[12])).To(Equal([]string{`dog`, `1`, `1`}))
+
+		// every row of the table lines up
+		Expect(len(lines[2])).To(Equal(len(lines[7])))
+		Expect(len(lines[5])).To(Equal(len(lines[7])))
+		Expect(len(lines[10])).To(Equal(len(lines[11])))
+	})
+})
@@ -0,0 +1,35 @@
+package metrics
+
+// TopK counts the examples whose class is among the K largest
+// outputs of the network
+type TopK struct {
+	K       int
+	Correct int
+	Total   int
+}
+
+// InTopK reports whether output[class] is among the k largest
+// outputs, ties counting in favour of the class
+func InTopK(output []float64, class, k int) bool {
+	larger := 0
+
+	for _, x := range output {
+		if x > output[class] {
+			larger++
+		}
+	}
+
+	return larger < k
+}
+
+func (t *TopK) Add(output, desired []float64) {
+	t.Total++
+
+	if InTopK(output, ArgMax(desired), t.K) {
+		t.Correct++
+	}
+}
+
+func (t *TopK) Accuracy() float64 {
+	return ratio(t.Correct, t.Total)
+}
@@ -0,0 +1,144 @@
+package nn
+
+import (
+	"math"
+)
+
+type relu struct{}
+
+// max(0, z)
+func (r relu) Fn(zs ...float64) float64 {
+	return math.Max(0, zs[0])
+}
+
+func (r relu) Prime(zs ...float64) float64 {
+	if zs[0] > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+type leakyReLU struct {
+	alpha float64
+}
+
+// z if z > 0 otherwise alpha * z
+func (lr leakyReLU) Fn(zs ...float64) float64 {
+	if zs[0] > 0 {
+		return zs[0]
+	}
+
+	return lr.alpha * zs[0]
+}
+
+func (lr leakyReLU) Prime(zs ...float64) float64 {
+	if zs[0] > 0 {
+		return 1
+	}
+
+	return lr.alpha
+}
+
+func NewLeakyReLU(alpha float64) Differentiable {
+	return leakyReLU{alpha}
+}
+
+type elu struct {
+	alpha float64
+}
+
+// z if z > 0 otherwise alpha * (e^z - 1)
+// Expm1 keeps precision for z close to 0
+func (e elu) Fn(zs ...float64) float64 {
+	if zs[0] > 0 {
+		return zs[0]
+	}
+
+	return e.alpha * math.Expm1(zs[0])
+}
+
+func (e elu) Prime(zs ...float64) float64 {
+	if zs[0] > 0 {
+		return 1
+	}
+
+	return e.alpha * math.Exp(zs[0])
+}
+
+func NewELU(alpha float64) Differentiable {
+	return elu{alpha}
+}
+
+type tanh struct{}
+
+func (t tanh) Fn(zs ...float64) float64 {
+	return math.Tanh(zs[0])
+}
+
+func (t tanh) Prime(zs ...float64) float64 {
+	th := math.Tanh(zs[0])
+	return 1 - th*th
+}
+
+type softplus struct{}
+
+// log(1 + e^z) rewritten as max(z, 0) + log(1 + e^-|z|)
+// so e^z never overflows for large z
+func (s softplus) Fn(zs ...float64) float64 {
+	return math.Max(zs[0], 0) + math.Log1p(math.Exp(-math.Abs(zs[0])))
+}
+
+// the derivative of softplus is the sigmoid
+func (s softplus) Prime(zs ...float64) float64 {
+	return logistic(zs[0])
+}
+
+type gelu struct{}
+
+// z * Φ(z) where Φ is the standard normal CDF
+func (g gelu) Fn(zs ...float64) float64 {
+	return zs[0] * normalCDF(zs[0])
+}
+
+// Φ(z) + z * φ(z)
+func (g gelu) Prime(zs ...float64) float64 {
+	z := zs[0]
+	return normalCDF(z) + z*math.Exp(-z*z/2)/math.Sqrt(2*math.Pi)
+}
+
+type swish struct{}
+
+// z * sigmoid(z)
+func (s swish) Fn(zs ...float64) float64 {
+	return zs[0] * logistic(zs[0])
+}
+
+// sigmoid(z) + z * sigmoid(z) * (1 - sigmoid(z))
+func (s swish) Prime(zs ...float64) float64 {
+	sig := logistic(zs[0])
+	return sig + zs[0]*sig*(1-sig)
+}
+
+// logistic is the sigmoid evaluated so that e^-z
+// is never computed for large negative z
+func logistic(z float64) float64 {
+	if z >= 0 {
+		return 1 / (1 + math.Exp(-z))
+	}
+
+	ez := math.Exp(z)
+	return ez / (1 + ez)
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+var ReLU = relu{}
+var LeakyReLU = NewLeakyReLU(0.01)
+var ELU = NewELU(1)
+var Tanh = tanh{}
+var Softplus = softplus{}
+var GELU = gelu{}
+var Swish = swish{}
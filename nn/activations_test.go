@@ -0,0 +1,97 @@
+package nn_test
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// central finite difference of f at z
+func numericPrime(f func(...float64) float64, z float64) float64 {
+	return CentralDifference(&z, func() float64 { return f(z) })
+}
+
+var _ = Describe("Activations", func() {
+	// points away from any kinks so the finite difference is valid
+	zs := []float64{-20, -3.5, -1, -0.25, 0.3, 1, 2.75, 8, 20}
+
+	table.DescribeTable("#Prime matches the finite difference of #Fn",
+		func(a Differentiable) {
+			for _, z := range zs {
+				Expect(a.Prime(z)).To(BeNumerically(`~`, numericPrime(a.Fn, z), 1e-6))
+			}
+		},
+		table.Entry(`Sigmoid`, Sigmoid),
+		table.Entry(`ReLU`, ReLU),
+		table.Entry(`LeakyReLU`, LeakyReLU),
+		table.Entry(`LeakyReLU(0.2)`, NewLeakyReLU(0.2)),
+		table.Entry(`ELU`, ELU),
+		table.Entry(`ELU(0.5)`, NewELU(0.5)),
+		table.Entry(`Tanh`, Tanh),
+		table.Entry(`Softplus`, Softplus),
+		table.Entry(`GELU`, GELU),
+		table.Entry(`Swish`, Swish),
+	)
+
+	table.DescribeTable("#Fn and #Prime stay finite for large inputs",
+		func(a Differentiable) {
+			for _, z := range []float64{-1000, -750, 750, 1000} {
+				Expect(math.IsNaN(a.Fn(z)) || math.IsInf(a.Fn(z), 0)).To(BeFalse())
+				Expect(math.IsNaN(a.Prime(z)) || math.IsInf(a.Prime(z), 0)).To(BeFalse())
+			}
+		},
+		table.Entry(`ReLU`, ReLU),
+		table.Entry(`LeakyReLU`, LeakyReLU),
+		table.Entry(`ELU`, ELU),
+		table.Entry(`Tanh`, Tanh),
+		table.Entry(`Softplus`, Softplus),
+		table.Entry(`GELU`, GELU),
+		table.Entry(`Swish`, Swish),
+	)
+
+	table.DescribeTable("#Fn",
+		func(a Differentiable, z, expected float64) {
+			Expect(a.Fn(z)).To(BeNumerically(`~`, expected, 1e-9))
+		},
+		table.Entry(`ReLU of a negative`, ReLU, -2.0, 0.0),
+		table.Entry(`ReLU of a positive`, ReLU, 2.0, 2.0),
+		table.Entry(`LeakyReLU of a negative`, LeakyReLU, -2.0, -0.02),
+		table.Entry(`ELU of a negative`, ELU, -1.0, math.Exp(-1)-1),
+		table.Entry(`Tanh`, Tanh, 0.5, math.Tanh(0.5)),
+		table.Entry(`Softplus of 0`, Softplus, 0.0, math.Log(2)),
+		table.Entry(`Softplus of a large input`, Softplus, 1000.0, 1000.0),
+		table.Entry(`GELU of 0`, GELU, 0.0, 0.0),
+		table.Entry(`GELU of 1`, GELU, 1.0, 0.8413447460685429),
+		table.Entry(`Swish of 1`, Swish, 1.0, 1/(1+math.Exp(-1))),
+		table.Entry(`Swish of a large negative`, Swish, -1000.0, 0.0),
+	)
+
+	Describe("ToOP", func() {
+		It("can be shared by the goroutines of the parallel kernels", func() {
+			workers, threshold := la.Workers, la.Threshold
+			la.Workers, la.Threshold = 4, 0
+			defer func() { la.Workers, la.Threshold = workers, threshold }()
+
+			m := la.RandMatrix(50, 40)
+
+			Expect(la.MMapDP(m, ToOP(Tanh.Fn))).To(Equal(la.MMap(m, math.Tanh)))
+			Expect(la.MAggDP(m, m, ToBOP(Quadratic.Fn))).To(Equal(la.MMapD(m, func(float64) float64 { return 0 })))
+		})
+	})
+
+	Describe("#Save", func() {
+		It("knows every activation by name", func() {
+			for _, a := range []Differentiable{ReLU, LeakyReLU, ELU, Tanh, Softplus, GELU, Swish} {
+				name, err := NameOf(a)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Lookup(name)).To(Equal(a))
+			}
+		})
+	})
+})
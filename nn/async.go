@@ -0,0 +1,127 @@
+package nn
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+var ErrAsyncUnsupported = errors.New(`AsyncRun only supports gradient descent without checkpoints`)
+
+// AsyncRun trains the network Hogwild style: sgd.Workers goroutines
+// (at least one) take mini batches from a shared queue and subtract
+// their gradients from the shared weights and biases in place without
+// any locking. Updates may overwrite each other, which works out when
+// each mini batch only moves a small part of the parameters, so runs
+// are not reproducible even with a fixed Seed.
+//
+// Only plain gradient descent can be applied this way and checkpoints
+// are not written. Callbacks are notified at the start and end of every
+// epoch and after evaluations but not after every mini batch.
+func (sgd SGD) AsyncRun(trainingData []Example, epochs, miniBatchSize int) error {
+	if sgd.Checkpoint != nil || (sgd.Optimizer != nil && sgd.Optimizer != GradientDescent) {
+		return ErrAsyncUnsupported
+	}
+
+	trainingData, validation := sgd.Evaluation.split(trainingData, sgd.Seed)
+	numBatches := len(trainingData) / miniBatchSize
+	workers := sgd.Workers
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	cbs := callbacks(sgd.Callbacks)
+	start := time.Now()
+
+	state := &TrainingState{
+		Epochs:     epochs,
+		NumBatches: numBatches,
+		Net:        sgd.Net,
+	}
+
+	// every worker drops units with its own source
+	dropouts := sgd.Net.Dropout.split(workers)
+
+	for i := 0; i < epochs; i++ {
+		shuffled := shuffle(trainingData, sgd.Seed, i)
+		epochStart := time.Now()
+		costs := make([]float64, numBatches)
+
+		state.Epoch, state.Batch, state.Step = i, 0, i*numBatches
+		cbs.epochStart(state)
+
+		if state.Stop {
+			break
+		}
+
+		queue := make(chan int, numBatches)
+
+		for j := 0; j < numBatches; j++ {
+			queue <- j
+		}
+
+		close(queue)
+
+		var wg sync.WaitGroup
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+
+			go func(net Network) {
+				defer wg.Done()
+				ws := net.newWorkspace(sgd.Activation, sgd.Cost, miniBatchSize)
+
+				for j := range queue {
+					inputs, desired := ws.load(shuffled[(j * miniBatchSize):((j + 1) * miniBatchSize)])
+					deltaW, deltaB, cost := net.parallelMBackProp(ws, inputs, desired, sgd.Activation, sgd.Cost, 1, len(cbs) > 0)
+					sgd.Regularizer.addGradient(net, deltaW, deltaB)
+					// the matrices are updated in place so every
+					// worker sees the steps of the others
+					GradientDescent.Update(net, deltaW, deltaB, sgd.rate(i, (i*numBatches)+j))
+					costs[j] = cost
+				}
+			}(sgd.Net.withDropout(dropouts[w]))
+		}
+
+		wg.Wait()
+
+		state.Batch, state.Step = numBatches-1, ((i+1)*numBatches)-1
+		state.Eta = sgd.rate(i, state.Step)
+
+		if numBatches > 0 {
+			state.BatchCost = costs[numBatches-1]
+			state.TrainCost = la.AddReduce(costs) / float64(numBatches)
+		}
+
+		if len(validation) > 0 && sgd.Evaluation.due(i) {
+			state.Correct, state.Cost, state.Report = sgd.evaluate(validation)
+			state.Total, state.Evaluated = len(validation), true
+
+			if o, ok := sgd.Schedule.(observer); ok {
+				o.Observe(state.Cost)
+			}
+
+			state.EpochTime, state.Elapsed = time.Since(epochStart), time.Since(start)
+			cbs.evaluated(state)
+		}
+
+		state.EpochTime, state.Elapsed = time.Since(epochStart), time.Since(start)
+		cbs.epochEnd(state)
+
+		if state.Stop {
+			break
+		}
+	}
+
+	cbs.trainEnd(state)
+	return nil
+}
+
+// withDropout is a shallow copy of the network dropping with d
+func (n Network) withDropout(d *Dropout) Network {
+	n.Dropout = d
+	return n
+}
@@ -0,0 +1,126 @@
+package nn_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AsyncRun", func() {
+	var sgd SGD
+	var examples []Example
+
+	BeforeEach(func() {
+		net, _ := NewNetwork([]int{16, 4})
+		examples = generateExamples(400)
+		sgd = SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net, Workers: 4}
+	})
+
+	It("trains the network", func() {
+		_, origCost := Evaluate(sgd, examples, binaryMatcher{})
+
+		Expect(sgd.AsyncRun(examples, 5, 10)).To(Succeed())
+
+		_, newCost := Evaluate(sgd, examples, binaryMatcher{})
+		Expect(newCost).To(BeNumerically(`<`, origCost))
+	})
+
+	It("notifies callbacks between epochs", func() {
+		var epochs []int
+		sgd.Evaluation = &Evaluation{Split: 0.1, Matcher: binaryMatcher{}}
+		sgd.Callbacks = []Callback{CallbackFuncs{
+			OnBatchEnd: func(s *TrainingState) { Fail(`no batch callbacks`) },
+			OnEpochEnd: func(s *TrainingState) {
+				Expect(s.Evaluated).To(BeTrue())
+				Expect(s.Total).To(Equal(40))
+				Expect(s.TrainCost).To(BeNumerically(`>`, 0))
+				epochs = append(epochs, s.Epoch)
+				s.Stop = s.Epoch == 1
+			},
+		}}
+
+		Expect(sgd.AsyncRun(examples, 5, 10)).To(Succeed())
+		Expect(epochs).To(Equal([]int{0, 1}))
+	})
+
+	It("returns an error for optimizers with state or checkpoints", func() {
+		sgd.Optimizer = NewMomentum(0.9)
+		Expect(sgd.AsyncRun(examples, 1, 10)).To(Equal(ErrAsyncUnsupported))
+
+		sgd.Optimizer = GradientDescent
+		sgd.Checkpoint = &Checkpointer{Filename: `test-async`, Epochs: 1}
+		Expect(sgd.AsyncRun(examples, 1, 10)).To(Equal(ErrAsyncUnsupported))
+	})
+})
+
+// mnistLike makes n examples shaped like MNIST digits,
+// a noisy copy of one of 10 random 784 pixel prototypes
+func mnistLike(n int) []Example {
+	r := rand.New(rand.NewSource(1))
+	prototypes := make([][]float64, 10)
+
+	for i := range prototypes {
+		prototypes[i] = make([]float64, 784)
+
+		for j := range prototypes[i] {
+			// roughly a fifth of the pixels are on
+			if r.Float64() < 0.2 {
+				prototypes[i][j] = 1
+			}
+		}
+	}
+
+	out := make([]Example, n)
+
+	for i := range out {
+		label := r.Intn(10)
+		in := make([]float64, 784)
+
+		for j := range in {
+			in[j] = prototypes[label][j] * r.Float64()
+		}
+
+		y := make([]float64, 10)
+		y[label] = 1
+		out[i] = fixedEx{in, y}
+	}
+
+	return out
+}
+
+func benchmarkTraining(b *testing.B, train func(SGD, []Example) error) {
+	data := mnistLike(2000)
+	test := mnistLike(500)
+
+	var cost float64
+
+	for i := 0; i < b.N; i++ {
+		net, _ := NewNetwork([]int{784, 30, 10})
+		sgd := SGD{Activation: Sigmoid, Cost: CrossEntropy, Eta: 0.5, Net: net, Workers: 4}
+
+		if e := train(sgd, data); e != nil {
+			b.Fatal(e)
+		}
+
+		_, cost = Evaluate(sgd, test, binaryMatcher{})
+	}
+
+	b.ReportMetric(float64(len(data)*b.N)/b.Elapsed().Seconds(), `examples/s`)
+	b.ReportMetric(cost, `cost`)
+}
+
+func BenchmarkSyncTraining(b *testing.B) {
+	benchmarkTraining(b, func(sgd SGD, data []Example) error {
+		return sgd.MRun(data, 1, 10)
+	})
+}
+
+func BenchmarkAsyncTraining(b *testing.B) {
+	benchmarkTraining(b, func(sgd SGD, data []Example) error {
+		return sgd.AsyncRun(data, 1, 10)
+	})
+}
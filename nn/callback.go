@@ -0,0 +1,238 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hayden-erickson/neural-network/la"
+	"github.com/hayden-erickson/neural-network/metrics"
+)
+
+// TrainingState describes the progress of an SGD run. The same state
+// is passed to every Callback and is updated as the run goes on.
+type TrainingState struct {
+	// the current epoch and mini batch, counted from 0
+	Epoch      int
+	Batch      int
+	Epochs     int
+	NumBatches int
+	// Step counts mini batches across epochs
+	Step int
+	// Eta is the learning rate used for the last mini batch
+	Eta float64
+	// BatchCost is the average cost of the last mini batch and
+	// TrainCost the average over the mini batches of this epoch,
+	// both measured while training (with dropout applied)
+	BatchCost float64
+	TrainCost float64
+	// the results of the last evaluation, see Evaluation
+	Evaluated bool
+	Correct   int
+	Total     int
+	Cost      float64
+	// Report has the confusion matrix and per class scores of the
+	// last evaluation if the Evaluation asked for Metrics
+	Report *metrics.Report
+	// how long the last mini batch, the current epoch
+	// and the whole run have taken
+	BatchTime time.Duration
+	EpochTime time.Duration
+	Elapsed   time.Duration
+	// Net is the network being trained
+	Net Network
+	// setting Stop ends training once the callback returns
+	Stop bool
+}
+
+// Accuracy is the fraction of the last evaluation that was correct
+func (s *TrainingState) Accuracy() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+
+	return float64(s.Correct) / float64(s.Total)
+}
+
+// Callback is notified by MRun and Resume as training progresses.
+// EpochEnd is called after the epoch's evaluation (if any) so it
+// sees the latest cost and accuracy.
+type Callback interface {
+	EpochStart(s *TrainingState)
+	BatchEnd(s *TrainingState)
+	Evaluated(s *TrainingState)
+	EpochEnd(s *TrainingState)
+}
+
+// TrainEnder is implemented by a Callback wanting to know when
+// training ends, whether all epochs ran or a callback stopped it.
+// TrainEnd is called after every callback has seen the last epoch.
+type TrainEnder interface {
+	TrainEnd(s *TrainingState)
+}
+
+// CallbackFuncs is a Callback calling whichever of its functions are set
+type CallbackFuncs struct {
+	OnEpochStart func(s *TrainingState)
+	OnBatchEnd   func(s *TrainingState)
+	OnEvaluated  func(s *TrainingState)
+	OnEpochEnd   func(s *TrainingState)
+	OnTrainEnd   func(s *TrainingState)
+}
+
+func (cf CallbackFuncs) EpochStart(s *TrainingState) {
+	if cf.OnEpochStart != nil {
+		cf.OnEpochStart(s)
+	}
+}
+
+func (cf CallbackFuncs) BatchEnd(s *TrainingState) {
+	if cf.OnBatchEnd != nil {
+		cf.OnBatchEnd(s)
+	}
+}
+
+func (cf CallbackFuncs) Evaluated(s *TrainingState) {
+	if cf.OnEvaluated != nil {
+		cf.OnEvaluated(s)
+	}
+}
+
+func (cf CallbackFuncs) EpochEnd(s *TrainingState) {
+	if cf.OnEpochEnd != nil {
+		cf.OnEpochEnd(s)
+	}
+}
+
+func (cf CallbackFuncs) TrainEnd(s *TrainingState) {
+	if cf.OnTrainEnd != nil {
+		cf.OnTrainEnd(s)
+	}
+}
+
+// Evaluation runs Evaluate with Matcher at the end of every Every
+// epochs (every epoch if Every is 0). Without a Matcher an output is
+// correct when its largest value is at the desired class. Data is evaluated if set,
+// otherwise Split of the training data is held out for validation.
+// A Schedule with an Observe method such as ReduceOnPlateau is given
+// the cost of every evaluation. With Metrics every evaluation also
+// reports the confusion matrix over the outputs of the network and the
+// top k accuracy for each of TopK.
+type Evaluation struct {
+	Data    []Example
+	Split   float64
+	Matcher la.Matcher
+	Every   int
+	Metrics bool
+	TopK    []int
+}
+
+// evaluate runs the Evaluation on the validation data
+func (sgd SGD) evaluate(validation []Example) (correct int, cost float64, report *metrics.Report) {
+	if sgd.Evaluation.Metrics {
+		report = sgd.Net.newReport(sgd.Evaluation.TopK)
+	}
+
+	matcher := sgd.Evaluation.Matcher
+
+	if matcher == nil {
+		matcher = argMaxMatcher{}
+	}
+
+	correct, cost = evaluate(sgd, validation, matcher, report)
+	return correct, cost, report
+}
+
+// argMaxMatcher matches outputs standing for the same class
+type argMaxMatcher struct{}
+
+func (m argMaxMatcher) Match(a, b []float64) bool {
+	return metrics.ArgMax(a) == metrics.ArgMax(b)
+}
+
+func (ev *Evaluation) due(epoch int) bool {
+	return ev.Every <= 1 || (epoch+1)%ev.Every == 0
+}
+
+// split returns the examples to train on and the examples to evaluate,
+// none when there is no Evaluation
+func (ev *Evaluation) split(trainingData []Example, seed int64) (training, validation []Example) {
+	switch {
+	case ev == nil:
+		return trainingData, nil
+	case len(ev.Data) > 0:
+		return trainingData, ev.Data
+	case ev.Split > 0:
+		return SplitValidation(trainingData, ev.Split, seed)
+	}
+
+	return trainingData, nil
+}
+
+// SplitValidation holds out the given fraction of the examples, chosen
+// at random by the seed, as a validation set
+func SplitValidation(examples []Example, fraction float64, seed int64) (training, validation []Example) {
+	n := int(math.Round(fraction * float64(len(examples))))
+	perm := rand.New(rand.NewSource(seed)).Perm(len(examples))
+
+	for i, p := range perm {
+		if i < n {
+			validation = append(validation, examples[p])
+		} else {
+			training = append(training, examples[p])
+		}
+	}
+
+	return training, validation
+}
+
+// observer is implemented by schedules that react to evaluations
+type observer interface {
+	Observe(cost float64)
+}
+
+type callbacks []Callback
+
+func (cs callbacks) epochStart(s *TrainingState) {
+	for _, c := range cs {
+		c.EpochStart(s)
+	}
+}
+
+func (cs callbacks) batchEnd(s *TrainingState) {
+	for _, c := range cs {
+		c.BatchEnd(s)
+	}
+}
+
+func (cs callbacks) evaluated(s *TrainingState) {
+	for _, c := range cs {
+		c.Evaluated(s)
+	}
+}
+
+func (cs callbacks) epochEnd(s *TrainingState) {
+	for _, c := range cs {
+		c.EpochEnd(s)
+	}
+}
+
+func (cs callbacks) trainEnd(s *TrainingState) {
+	for _, c := range cs {
+		if te, ok := c.(TrainEnder); ok {
+			te.TrainEnd(s)
+		}
+	}
+}
+
+// batchCost is the average cost of the examples in the columns
+// of the output layer's weighted input and activation
+func batchCost(weighted, actual, desired la.Matrix, a, c Differentiable) float64 {
+	total := 0.0
+
+	for j := 0; j < actual.Shape()[1]; j++ {
+		total += exampleCost(weighted.Col(j), actual.Col(j), desired.Col(j), a, c)
+	}
+
+	return total / float64(actual.Shape()[1])
+}
@@ -0,0 +1,142 @@
+package nn_test
+
+import (
+	"github.com/hayden-erickson/neural-network/metrics"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Callback", func() {
+	var sgd SGD
+	var examples []Example
+	var events []string
+
+	// records every call so the order can be checked
+	recorder := func(events *[]string) Callback {
+		return CallbackFuncs{
+			OnEpochStart: func(s *TrainingState) { *events = append(*events, `start`) },
+			OnBatchEnd:   func(s *TrainingState) { *events = append(*events, `batch`) },
+			OnEvaluated:  func(s *TrainingState) { *events = append(*events, `evaluated`) },
+			OnEpochEnd:   func(s *TrainingState) { *events = append(*events, `end`) },
+			OnTrainEnd:   func(s *TrainingState) { *events = append(*events, `trained`) },
+		}
+	}
+
+	BeforeEach(func() {
+		net, _ := NewNetwork([]int{16, 4})
+		examples = generateExamples(40)
+		events = nil
+
+		sgd = SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net}
+	})
+
+	Describe("#MRun", func() {
+		It("notifies the callbacks in order", func() {
+			sgd.Callbacks = []Callback{recorder(&events)}
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}}
+
+			Expect(sgd.MRun(examples, 2, 20)).To(Succeed())
+
+			Expect(events).To(Equal([]string{
+				`start`, `batch`, `batch`, `evaluated`, `end`,
+				`start`, `batch`, `batch`, `evaluated`, `end`,
+				`trained`,
+			}))
+		})
+
+		It("only evaluates every N epochs", func() {
+			sgd.Callbacks = []Callback{recorder(&events)}
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}, Every: 2}
+
+			sgd.MRun(examples, 2, 40)
+
+			Expect(events).To(Equal([]string{`start`, `batch`, `end`, `start`, `batch`, `evaluated`, `end`, `trained`}))
+		})
+
+		It("reports the progress of the run", func() {
+			var batches []TrainingState
+			var evaluated TrainingState
+
+			sgd.Schedule = StepDecay{Drop: 0.5, Every: 1}
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}}
+			sgd.Callbacks = []Callback{CallbackFuncs{
+				OnBatchEnd:  func(s *TrainingState) { batches = append(batches, *s) },
+				OnEvaluated: func(s *TrainingState) { evaluated = *s },
+			}}
+
+			sgd.MRun(examples, 2, 10)
+
+			Expect(batches).To(HaveLen(8))
+			Expect(batches[5].Epoch).To(Equal(1))
+			Expect(batches[5].Batch).To(Equal(1))
+			Expect(batches[5].Step).To(Equal(5))
+			Expect(batches[5].NumBatches).To(Equal(4))
+			Expect(batches[5].Eta).To(Equal(1.5))
+			Expect(batches[5].BatchCost).To(BeNumerically(`>`, 0))
+			Expect(batches[5].TrainCost).To(BeNumerically(`~`, (batches[4].BatchCost+batches[5].BatchCost)/2, 1e-12))
+			Expect(batches[7].Elapsed).To(BeNumerically(`>=`, batches[0].Elapsed))
+
+			correct, cost := Evaluate(sgd, examples, binaryMatcher{})
+
+			Expect(evaluated.Evaluated).To(BeTrue())
+			Expect(evaluated.Correct).To(Equal(correct))
+			Expect(evaluated.Cost).To(Equal(cost))
+			Expect(evaluated.Accuracy()).To(Equal(float64(correct) / 40))
+		})
+
+		It("reports the metrics of every evaluation when asked to", func() {
+			var reports []*metrics.Report
+
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}, Metrics: true, TopK: []int{2}}
+			sgd.Callbacks = []Callback{CallbackFuncs{
+				OnEvaluated: func(s *TrainingState) { reports = append(reports, s.Report) },
+			}}
+
+			sgd.MRun(examples, 2, 10)
+
+			Expect(reports).To(HaveLen(2))
+			Expect(reports[0]).ToNot(BeIdenticalTo(reports[1]))
+			Expect(reports[1]).To(Equal(EvaluateReport(sgd, examples, 2)))
+			Expect(reports[1].Confusion.Classes()).To(Equal(4))
+			Expect(reports[1].Confusion.Total()).To(Equal(40))
+			Expect(reports[1].TopK[0].Total).To(Equal(40))
+		})
+
+		It("stops when a callback asks it to", func() {
+			sgd.Callbacks = []Callback{recorder(&events), CallbackFuncs{
+				OnBatchEnd: func(s *TrainingState) {
+					s.Stop = s.Step == 2
+				},
+			}}
+
+			Expect(sgd.MRun(examples, 5, 10)).To(Succeed())
+			Expect(events).To(Equal([]string{`start`, `batch`, `batch`, `batch`, `trained`}))
+		})
+
+		It("matches the largest outputs without a Matcher", func() {
+			var evaluated TrainingState
+
+			sgd.Evaluation = &Evaluation{Data: examples}
+			sgd.Callbacks = []Callback{CallbackFuncs{
+				OnEvaluated: func(s *TrainingState) { evaluated = *s },
+			}}
+
+			Expect(sgd.MRun(examples, 1, 10)).To(Succeed())
+			Expect(evaluated.Correct).To(Equal(EvaluateReport(sgd, examples).Confusion.Correct()))
+		})
+
+		It("gives the cost of every evaluation to the schedule", func() {
+			plateau := NewReduceOnPlateau(0.5, 1)
+			sgd.Schedule = plateau
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}}
+			// the same cost every epoch so the rate keeps halving
+			sgd.Eta = 0
+
+			sgd.MRun(examples, 3, 20)
+
+			Expect(plateau.Rate(1, 3, 0)).To(Equal(0.25))
+		})
+	})
+})
@@ -0,0 +1,474 @@
+package nn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+var ErrMismatchedState = errors.New(`Checkpointed state does not match the schedule and callbacks`)
+
+// magic number written at the head of every checkpoint ("NCKP")
+const checkpointMagicNum uint32 = 0x4E434B50
+const checkpointVersion uint32 = 4
+
+// the optimizer state holds a few vectors for the weights and biases of
+// every layer, a file with more is corrupt
+const maxVectors = 16 * maxLayers
+
+// more callbacks than this means the file is corrupt
+const maxCallbacks = 1 << 12
+
+// Stateful is implemented by the schedules and callbacks which change
+// how training continues as it goes, like ReduceOnPlateau and
+// EarlyStopping. Checkpoints save their state the way they save the
+// optimizer's and Resume restores it.
+type Stateful interface {
+	State() [][]float64
+	SetState(n Network, state [][]float64) error
+}
+
+// stateOf is the State of x, none if it isn't Stateful
+func stateOf(x interface{}) [][]float64 {
+	if st, ok := x.(Stateful); ok {
+		return st.State()
+	}
+
+	return nil
+}
+
+// setState restores the state of x, which must be empty unless x
+// is Stateful. A Stateful x rejects a missing state.
+func setState(x interface{}, n Network, state [][]float64) error {
+	if st, ok := x.(Stateful); ok {
+		return st.SetState(n, state)
+	}
+
+	if len(state) != 0 {
+		return ErrMismatchedState
+	}
+
+	return nil
+}
+
+// Checkpointer configures how often SGD writes its training state
+// to Filename. Setting both Epochs and Batches saves on either.
+type Checkpointer struct {
+	Filename string
+	// save after every N completed epochs
+	Epochs int
+	// save after every N completed mini batches
+	Batches int
+}
+
+// Checkpoint is everything needed to continue a training run
+// exactly where it left off
+type Checkpoint struct {
+	Net        Network
+	Activation Differentiable
+	Cost       Differentiable
+	Eta        float64
+	Seed       int64
+	// the per-parameter state returned by Optimizer.State
+	OptimizerState [][]float64
+	// the State of the Schedule and of every callback,
+	// empty for those which aren't Stateful
+	ScheduleState [][]float64
+	CallbackState [][][]float64
+	// the cursor of the next mini batch to run
+	Epoch int
+	Batch int
+	// the shape of the run being checkpointed
+	Epochs        int
+	MiniBatchSize int
+	// version is the format the checkpoint was loaded from
+	version uint32
+}
+
+// due reports whether a checkpoint should be written after
+// mini batch j of epoch i has been applied
+func (c *Checkpointer) due(i, j, numBatches int) bool {
+	if c == nil {
+		return false
+	}
+
+	if c.Batches > 0 && ((i*numBatches)+j+1)%c.Batches == 0 {
+		return true
+	}
+
+	return c.Epochs > 0 && j+1 == numBatches && (i+1)%c.Epochs == 0
+}
+
+func (c *Checkpointer) save(cp Checkpoint) error {
+	// write to a temporary file first so a crash
+	// mid write never clobbers the last checkpoint
+	tmp := c.Filename + `.tmp`
+
+	if e := SaveCheckpointFile(tmp, cp); e != nil {
+		return e
+	}
+
+	return os.Rename(tmp, c.Filename)
+}
+
+func (sgd SGD) checkpoint(epoch, batch, epochs, miniBatchSize int) Checkpoint {
+	callbackState := make([][][]float64, len(sgd.Callbacks))
+
+	for i, c := range sgd.Callbacks {
+		callbackState[i] = stateOf(c)
+	}
+
+	return Checkpoint{
+		Net:            sgd.Net,
+		Activation:     sgd.Activation,
+		Cost:           sgd.Cost,
+		Eta:            sgd.Eta,
+		Seed:           sgd.Seed,
+		OptimizerState: sgd.optimizer().State(),
+		ScheduleState:  stateOf(sgd.Schedule),
+		CallbackState:  callbackState,
+		Epoch:          epoch,
+		Batch:          batch,
+		Epochs:         epochs,
+		MiniBatchSize:  miniBatchSize,
+	}
+}
+
+// Resume loads the checkpoint in filename into the SGD and continues
+// the checkpointed run on the same training data. The Optimizer and
+// Checkpointer already set on the SGD keep being used with the
+// optimizer state restored from the checkpoint, as do the Schedule
+// and Callbacks if they are Stateful. They must match those of the
+// checkpointed run, ErrMismatchedState is returned otherwise. Dropout
+// is restored too, dropping the same units as the uninterrupted run
+// would if its source was created by NewDropout.
+func (sgd *SGD) Resume(filename string, trainingData []Example) error {
+	cp, e := LoadCheckpointFile(filename)
+
+	if e != nil {
+		return e
+	}
+
+	// checkpoints from before dropout was saved keep the caller's
+	if cp.version < 3 {
+		cp.Net.Dropout = sgd.Net.Dropout
+	}
+
+	sgd.Net = cp.Net
+	sgd.Activation = cp.Activation
+	sgd.Cost = cp.Cost
+	sgd.Eta = cp.Eta
+	sgd.Seed = cp.Seed
+
+	if e = sgd.optimizer().SetState(cp.Net, cp.OptimizerState); e != nil {
+		return e
+	}
+
+	if e = setState(sgd.Schedule, cp.Net, cp.ScheduleState); e != nil {
+		return e
+	}
+
+	if len(cp.CallbackState) > len(sgd.Callbacks) {
+		return ErrMismatchedState
+	}
+
+	for i, c := range sgd.Callbacks {
+		var state [][]float64
+
+		if i < len(cp.CallbackState) {
+			state = cp.CallbackState[i]
+		}
+
+		if e = setState(c, cp.Net, state); e != nil {
+			return e
+		}
+	}
+
+	return sgd.train(trainingData, cp.Epoch, cp.Batch, cp.Epochs, cp.MiniBatchSize)
+}
+
+// SaveCheckpoint writes the training state followed
+// by the network in the format written by Save.
+// Version 2 added the optimizer state, 3 dropout
+// and 4 the state of the schedule and callbacks.
+func SaveCheckpoint(w io.Writer, cp Checkpoint) error {
+	bw := bufio.NewWriter(w)
+
+	header := []uint32{
+		checkpointMagicNum,
+		checkpointVersion,
+		uint32(cp.Epoch),
+		uint32(cp.Batch),
+		uint32(cp.Epochs),
+		uint32(cp.MiniBatchSize),
+	}
+
+	if e := binary.Write(bw, binary.BigEndian, header); e != nil {
+		return e
+	}
+
+	if e := binary.Write(bw, binary.BigEndian, cp.Seed); e != nil {
+		return e
+	}
+
+	if e := binary.Write(bw, binary.BigEndian, cp.Eta); e != nil {
+		return e
+	}
+
+	if e := writeVectors(bw, cp.OptimizerState); e != nil {
+		return e
+	}
+
+	if e := writeDropout(bw, cp.Net.Dropout); e != nil {
+		return e
+	}
+
+	if e := writeVectors(bw, cp.ScheduleState); e != nil {
+		return e
+	}
+
+	if e := binary.Write(bw, binary.BigEndian, uint32(len(cp.CallbackState))); e != nil {
+		return e
+	}
+
+	for _, state := range cp.CallbackState {
+		if e := writeVectors(bw, state); e != nil {
+			return e
+		}
+	}
+
+	if e := Save(bw, cp.Net, cp.Activation, cp.Cost); e != nil {
+		return e
+	}
+
+	return bw.Flush()
+}
+
+func LoadCheckpoint(r io.Reader) (Checkpoint, error) {
+	var cp Checkpoint
+	var e error
+
+	br := bufio.NewReader(r)
+	header := make([]uint32, 6)
+
+	if e = binary.Read(br, binary.BigEndian, header); e != nil {
+		return Checkpoint{}, readErr(e)
+	}
+
+	if header[0] != checkpointMagicNum {
+		return Checkpoint{}, ErrIncorrectHeader
+	}
+
+	version := header[1]
+
+	if version < 1 || version > checkpointVersion {
+		return Checkpoint{}, ErrUnsupportedVersion
+	}
+
+	cp.version = version
+	cp.Epoch = int(header[2])
+	cp.Batch = int(header[3])
+	cp.Epochs = int(header[4])
+	cp.MiniBatchSize = int(header[5])
+
+	if e = binary.Read(br, binary.BigEndian, &cp.Seed); e != nil {
+		return Checkpoint{}, readErr(e)
+	}
+
+	if e = binary.Read(br, binary.BigEndian, &cp.Eta); e != nil {
+		return Checkpoint{}, readErr(e)
+	}
+
+	if version >= 2 {
+		if cp.OptimizerState, e = readVectors(br); e != nil {
+			return Checkpoint{}, e
+		}
+	}
+
+	var dropout *Dropout
+
+	if version >= 3 {
+		if dropout, e = readDropout(br); e != nil {
+			return Checkpoint{}, e
+		}
+	}
+
+	if version >= 4 {
+		if cp.ScheduleState, e = readVectors(br); e != nil {
+			return Checkpoint{}, e
+		}
+
+		var count uint32
+
+		if e = binary.Read(br, binary.BigEndian, &count); e != nil {
+			return Checkpoint{}, readErr(e)
+		}
+
+		if count > maxCallbacks {
+			return Checkpoint{}, ErrMismatchedFileSize
+		}
+
+		cp.CallbackState = make([][][]float64, count)
+
+		for i := range cp.CallbackState {
+			if cp.CallbackState[i], e = readVectors(br); e != nil {
+				return Checkpoint{}, e
+			}
+		}
+	}
+
+	cp.Net, cp.Activation, cp.Cost, e = Load(br)
+
+	if e != nil {
+		return Checkpoint{}, e
+	}
+
+	cp.Net.Dropout = dropout
+
+	return cp, nil
+}
+
+func SaveCheckpointFile(filename string, cp Checkpoint) error {
+	f, e := os.Create(filename)
+
+	if e != nil {
+		return e
+	}
+
+	if e = SaveCheckpoint(f, cp); e != nil {
+		f.Close()
+		return e
+	}
+
+	return f.Close()
+}
+
+func LoadCheckpointFile(filename string) (Checkpoint, error) {
+	f, e := os.Open(filename)
+
+	if e != nil {
+		return Checkpoint{}, e
+	}
+
+	defer f.Close()
+
+	return LoadCheckpoint(f)
+}
+
+// vectors are written as a count followed
+// by the length and data of each vector
+func writeVectors(w io.Writer, vs [][]float64) error {
+	if e := binary.Write(w, binary.BigEndian, uint32(len(vs))); e != nil {
+		return e
+	}
+
+	for _, v := range vs {
+		if e := binary.Write(w, binary.BigEndian, uint32(len(v))); e != nil {
+			return e
+		}
+
+		if e := binary.Write(w, binary.BigEndian, v); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func readVectors(r io.Reader) ([][]float64, error) {
+	var count uint32
+
+	if e := binary.Read(r, binary.BigEndian, &count); e != nil {
+		return nil, readErr(e)
+	}
+
+	if count > maxVectors {
+		return nil, ErrMismatchedFileSize
+	}
+
+	var out [][]float64
+
+	for i := 0; i < int(count); i++ {
+		var size uint32
+
+		if e := binary.Read(r, binary.BigEndian, &size); e != nil {
+			return nil, readErr(e)
+		}
+
+		if size > maxLayerParams {
+			return nil, ErrMismatchedFileSize
+		}
+
+		v := make([]float64, size)
+
+		if e := binary.Read(r, binary.BigEndian, v); e != nil {
+			return nil, readErr(e)
+		}
+
+		out = append(out, v)
+	}
+
+	return out, nil
+}
+
+// dropout is written as its keep probabilities, none without dropout,
+// followed by whether its source was created by NewDropout and the
+// state of that source
+func writeDropout(w io.Writer, d *Dropout) error {
+	var keep []float64
+	var seeded uint32
+	var state uint64
+
+	if d != nil {
+		keep = d.Keep
+
+		if s, ok := d.position(); ok {
+			seeded, state = 1, s
+		}
+	}
+
+	if e := writeVectors(w, [][]float64{keep}); e != nil {
+		return e
+	}
+
+	if e := binary.Write(w, binary.BigEndian, seeded); e != nil {
+		return e
+	}
+
+	return binary.Write(w, binary.BigEndian, state)
+}
+
+func readDropout(r io.Reader) (*Dropout, error) {
+	vs, e := readVectors(r)
+
+	if e != nil {
+		return nil, e
+	}
+
+	if len(vs) != 1 {
+		return nil, ErrMismatchedFileSize
+	}
+
+	var seeded uint32
+	var state uint64
+
+	if e = binary.Read(r, binary.BigEndian, &seeded); e != nil {
+		return nil, readErr(e)
+	}
+
+	if e = binary.Read(r, binary.BigEndian, &state); e != nil {
+		return nil, readErr(e)
+	}
+
+	switch {
+	case len(vs[0]) == 0:
+		return nil, nil
+	case seeded == 1:
+		return newDropoutAt(state, vs[0]...), nil
+	}
+
+	// any other source can't be restored so the global one is used
+	return &Dropout{Keep: vs[0]}, nil
+}
@@ -0,0 +1,269 @@
+package nn_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"os"
+
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// saving and loading gives us a copy which shares nothing with n
+var _ = Describe("Checkpoint", func() {
+	var filename string
+	var examples []Example
+	var net Network
+
+	BeforeEach(func() {
+		filename = `test-checkpoint`
+		examples = generateExamples(200)
+		net, _ = NewNetwork([]int{16, 6, 4})
+	})
+
+	AfterEach(func() {
+		os.Remove(filename)
+	})
+
+	Describe("#SaveCheckpoint and #LoadCheckpoint", func() {
+		It("round trips the training state", func() {
+			buf := &bytes.Buffer{}
+			cp := Checkpoint{
+				Net:           net,
+				Activation:    Sigmoid,
+				Cost:          CrossEntropy,
+				Eta:           0.25,
+				Seed:          -42,
+				Epoch:         3,
+				Batch:         17,
+				Epochs:        30,
+				MiniBatchSize: 10,
+			}
+
+			Expect(SaveCheckpoint(buf, cp)).To(Succeed())
+
+			loaded, err := LoadCheckpoint(buf)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Eta).To(Equal(cp.Eta))
+			Expect(loaded.Seed).To(Equal(cp.Seed))
+			Expect([]int{loaded.Epoch, loaded.Batch, loaded.Epochs, loaded.MiniBatchSize}).
+				To(Equal([]int{3, 17, 30, 10}))
+			Expect(loaded.Cost).To(Equal(CrossEntropy))
+
+			Expect(loaded.Net.Equal(net)).To(BeTrue())
+		})
+
+		It("round trips dropout and the position of its source", func() {
+			buf := &bytes.Buffer{}
+			net.Dropout = NewDropout(3, 0.5, 0.9)
+			net.Dropout.Rand.Float64()
+
+			Expect(SaveCheckpoint(buf, Checkpoint{Net: net, Activation: Sigmoid, Cost: Quadratic})).To(Succeed())
+			loaded, err := LoadCheckpoint(buf)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Net.Dropout.Keep).To(Equal([]float64{0.5, 0.9}))
+
+			for i := 0; i < 5; i++ {
+				Expect(loaded.Net.Dropout.Rand.Float64()).To(Equal(net.Dropout.Rand.Float64()))
+			}
+		})
+
+		It("falls back to the global source for any other source", func() {
+			buf := &bytes.Buffer{}
+			net.Dropout = &Dropout{Keep: []float64{0.5}, Rand: rand.New(rand.NewSource(1))}
+
+			SaveCheckpoint(buf, Checkpoint{Net: net, Activation: Sigmoid, Cost: Quadratic})
+			loaded, _ := LoadCheckpoint(buf)
+
+			Expect(loaded.Net.Dropout).To(Equal(&Dropout{Keep: []float64{0.5}}))
+
+			net.Dropout = nil
+			buf.Reset()
+			SaveCheckpoint(buf, Checkpoint{Net: net, Activation: Sigmoid, Cost: Quadratic})
+			loaded, _ = LoadCheckpoint(buf)
+
+			Expect(loaded.Net.Dropout).To(BeNil())
+		})
+
+		Context("Given optimizer state too large to be real", func() {
+			It("returns an error instead of allocating it", func() {
+				buf := &bytes.Buffer{}
+				SaveCheckpoint(buf, Checkpoint{Net: net, Activation: Sigmoid, Cost: Quadratic})
+				data := buf.Bytes()
+
+				// the vector count follows the header, seed and eta
+				binary.BigEndian.PutUint32(data[40:], 0xFFFFFFFF)
+				_, err := LoadCheckpoint(bytes.NewReader(data))
+				Expect(err).To(Equal(ErrMismatchedFileSize))
+
+				binary.BigEndian.PutUint32(data[40:], 1)
+				binary.BigEndian.PutUint32(data[44:], 0xFFFFFFFF)
+				_, err = LoadCheckpoint(bytes.NewReader(data))
+				Expect(err).To(Equal(ErrMismatchedFileSize))
+			})
+		})
+
+		Context("Given a saved network instead of a checkpoint", func() {
+			It("returns an error", func() {
+				buf := &bytes.Buffer{}
+				Save(buf, net, Sigmoid, Quadratic)
+
+				_, err := LoadCheckpoint(buf)
+				Expect(err).To(Equal(ErrIncorrectHeader))
+			})
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("checkpoints after every N epochs", func() {
+			sgd := SGD{
+				Activation: Sigmoid,
+				Cost:       Quadratic,
+				Eta:        3,
+				Net:        net,
+				Checkpoint: &Checkpointer{Filename: filename, Epochs: 1},
+			}
+
+			Expect(sgd.MRun(examples, 2, 20)).To(Succeed())
+
+			cp, err := LoadCheckpointFile(filename)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]int{cp.Epoch, cp.Batch}).To(Equal([]int{2, 0}))
+		})
+	})
+
+	Describe("#Resume", func() {
+		It("continues training bit for bit from the last checkpoint", func() {
+			uninterrupted := SGD{
+				Activation: Sigmoid,
+				Cost:       Quadratic,
+				Eta:        3,
+				Net:        net.Clone(),
+				Seed:       7,
+			}
+
+			checkpointed := uninterrupted
+			checkpointed.Net = net.Clone()
+			// 10 mini batches per epoch, the last checkpoint lands on batch 35
+			checkpointed.Checkpoint = &Checkpointer{Filename: filename, Batches: 7}
+
+			Expect(uninterrupted.MRun(examples, 4, 20)).To(Succeed())
+			Expect(checkpointed.MRun(examples, 4, 20)).To(Succeed())
+
+			cp, _ := LoadCheckpointFile(filename)
+			Expect([]int{cp.Epoch, cp.Batch}).To(Equal([]int{3, 5}))
+
+			resumed := SGD{}
+			Expect(resumed.Resume(filename, examples)).To(Succeed())
+
+			Expect(resumed.Net.Equal(uninterrupted.Net)).To(BeTrue())
+		})
+
+		It("continues training bit for bit with dropout", func() {
+			uninterrupted := SGD{
+				Activation: Sigmoid,
+				Cost:       Quadratic,
+				Eta:        3,
+				Net:        net.Clone(),
+				Seed:       7,
+				Workers:    2,
+			}
+
+			checkpointed := uninterrupted
+			checkpointed.Net = net.Clone()
+			checkpointed.Checkpoint = &Checkpointer{Filename: filename, Batches: 7}
+
+			uninterrupted.Net.Dropout = NewDropout(5, 0.8)
+			checkpointed.Net.Dropout = NewDropout(5, 0.8)
+
+			Expect(uninterrupted.MRun(examples, 4, 20)).To(Succeed())
+			Expect(checkpointed.MRun(examples, 4, 20)).To(Succeed())
+
+			cp, _ := LoadCheckpointFile(filename)
+			Expect([]int{cp.Epoch, cp.Batch}).To(Equal([]int{3, 5}))
+
+			resumed := SGD{Workers: 2}
+			Expect(resumed.Resume(filename, examples)).To(Succeed())
+
+			Expect(resumed.Net.Dropout.Keep).To(Equal([]float64{0.8}))
+			Expect(resumed.Net.Equal(uninterrupted.Net)).To(BeTrue())
+		})
+
+		It("continues training bit for bit with a stateful schedule and callbacks", func() {
+			run := func(cp *Checkpointer) (SGD, *ReduceOnPlateau, *EarlyStopping) {
+				plateau := NewReduceOnPlateau(0.5, 1)
+				stopping := NewEarlyStopping(10, 0)
+				stopping.RestoreBest = true
+
+				return SGD{
+					Activation: Sigmoid,
+					Cost:       Quadratic,
+					Eta:        3,
+					Net:        net.Clone(),
+					Seed:       7,
+					Schedule:   plateau,
+					Callbacks:  []Callback{stopping},
+					Evaluation: &Evaluation{Data: examples[:50], Matcher: binaryMatcher{}},
+					Checkpoint: cp,
+				}, plateau, stopping
+			}
+
+			uninterrupted, plateau, stopping := run(nil)
+			checkpointed, _, _ := run(&Checkpointer{Filename: filename, Batches: 7})
+
+			Expect(uninterrupted.MRun(examples, 4, 20)).To(Succeed())
+			Expect(checkpointed.MRun(examples, 4, 20)).To(Succeed())
+
+			resumed, resumedPlateau, resumedStopping := run(nil)
+			Expect(resumed.Resume(filename, examples)).To(Succeed())
+
+			Expect(resumed.Net.Equal(uninterrupted.Net)).To(BeTrue())
+			Expect(resumedPlateau.Rate(3, 0, 0)).To(Equal(plateau.Rate(3, 0, 0)))
+			Expect(resumedStopping.BestEpoch).To(Equal(stopping.BestEpoch))
+		})
+
+		Context("Given a stateful schedule the checkpoint has no state for", func() {
+			It("returns an error", func() {
+				checkpointed := SGD{
+					Activation: Sigmoid,
+					Cost:       Quadratic,
+					Eta:        3,
+					Net:        net,
+					Checkpoint: &Checkpointer{Filename: filename, Epochs: 1},
+				}
+
+				Expect(checkpointed.MRun(examples, 2, 20)).To(Succeed())
+
+				resumed := SGD{Schedule: NewReduceOnPlateau(0.5, 1)}
+				Expect(resumed.Resume(filename, examples)).To(Equal(ErrMismatchedState))
+
+				resumed = SGD{Callbacks: []Callback{NewEarlyStopping(1, 0)}}
+				Expect(resumed.Resume(filename, examples)).To(Equal(ErrMismatchedState))
+			})
+		})
+
+		Context("Given a checkpoint without dropout", func() {
+			It("trains without dropout", func() {
+				checkpointed := SGD{
+					Activation: Sigmoid,
+					Cost:       Quadratic,
+					Eta:        3,
+					Net:        net,
+					Checkpoint: &Checkpointer{Filename: filename, Epochs: 1},
+				}
+
+				Expect(checkpointed.MRun(examples, 2, 20)).To(Succeed())
+
+				resumed := SGD{Net: Network{Dropout: NewDropout(1, 0.5)}}
+				Expect(resumed.Resume(filename, examples)).To(Succeed())
+				Expect(resumed.Net.Dropout).To(BeNil())
+			})
+		})
+	})
+})
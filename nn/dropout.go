@@ -0,0 +1,159 @@
+package nn
+
+import (
+	"math/rand"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// Dropout randomly drops hidden units while the network is trained
+// by MBackProp and BackProp. Keep[i] is the probability of keeping
+// each unit output by the hidden layer Weights[i]; layers past the
+// end of Keep are never dropped. Kept units are scaled by 1 / Keep[i]
+// (inverted dropout) so nothing changes when the network is used
+// through Prop or Evaluate, where dropout is never applied.
+type Dropout struct {
+	Keep []float64
+	// Rand drives which units are dropped, the global source if nil
+	Rand *rand.Rand
+	// source is the source of Rand when created by NewDropout,
+	// the only kind of Rand a checkpoint can restore
+	source     *splitMix
+	sourceRand *rand.Rand
+}
+
+// NewDropout creates a Dropout with its own source seeded with seed.
+// Only the position of such a source is saved by checkpoints.
+func NewDropout(seed int64, keep ...float64) *Dropout {
+	return newDropoutAt(uint64(seed), keep...)
+}
+
+// newDropoutAt creates a Dropout whose source is in the given state
+func newDropoutAt(state uint64, keep ...float64) *Dropout {
+	source := &splitMix{state}
+	r := rand.New(source)
+
+	return &Dropout{Keep: keep, Rand: r, source: source, sourceRand: r}
+}
+
+// position is the state of the source created by NewDropout,
+// ok is false if Rand has been replaced by any other
+func (d *Dropout) position() (state uint64, ok bool) {
+	if d.source == nil || d.Rand != d.sourceRand {
+		return 0, false
+	}
+
+	return d.source.state, true
+}
+
+// clone copies d with a source of its own. A source created by
+// NewDropout is copied at its position, any other seeds the copy's.
+func (d *Dropout) clone() *Dropout {
+	keep := append([]float64(nil), d.Keep...)
+
+	if state, ok := d.position(); ok {
+		return newDropoutAt(state, keep...)
+	}
+
+	if d.Rand == nil {
+		return &Dropout{Keep: keep}
+	}
+
+	return NewDropout(d.Rand.Int63(), keep...)
+}
+
+// splitMix is SplitMix64, a generator whose whole state is a
+// single number so it can be checkpointed and copied
+type splitMix struct {
+	state uint64
+}
+
+func (s *splitMix) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+func (s *splitMix) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+func (s *splitMix) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (d *Dropout) float64() float64 {
+	if d.Rand == nil {
+		return rand.Float64()
+	}
+
+	return d.Rand.Float64()
+}
+
+// drops reports whether hidden layer i is dropped
+func (d *Dropout) drops(i int) bool {
+	return d != nil && i < len(d.Keep) && d.Keep[i] < 1
+}
+
+// mask returns a rows x cols matrix of 0s for dropped units and 1 / keep
+// for kept units of hidden layer i, or nil if layer i isn't dropped
+func (d *Dropout) mask(i, rows, cols int) la.Matrix {
+	if !d.drops(i) {
+		return nil
+	}
+
+	return d.maskInto(i, la.ZeroMatrix(rows, cols))
+}
+
+// maskInto fills m with a mask for hidden layer i, which must be dropped
+func (d *Dropout) maskInto(i int, m la.Matrix) la.Matrix {
+	keep := d.Keep[i]
+	rows, cols := la.Dims(m)
+
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			*m.At(r, c) = 0
+
+			if d.float64() < keep {
+				*m.At(r, c) = 1 / keep
+			}
+		}
+	}
+
+	return m
+}
+
+// split gives every one of n shards of a mini batch its own Dropout
+// seeded in order from d so shards can be dropped concurrently
+// with the same masks every time
+func (d *Dropout) split(n int) []*Dropout {
+	return d.splitInto(make([]*Dropout, n))
+}
+
+// splitInto is split reseeding the dropouts already in out
+// instead of allocating new ones
+func (d *Dropout) splitInto(out []*Dropout) []*Dropout {
+	for i := range out {
+		if d == nil {
+			out[i] = nil
+			continue
+		}
+
+		seed := rand.Int63()
+
+		if d.Rand != nil {
+			seed = d.Rand.Int63()
+		}
+
+		if out[i] == nil {
+			out[i] = NewDropout(seed, d.Keep...)
+		} else {
+			out[i].Keep = d.Keep
+			out[i].source.Seed(seed)
+		}
+	}
+
+	return out
+}
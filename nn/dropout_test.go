@@ -0,0 +1,113 @@
+package nn_test
+
+import (
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// the number of zero elements in v
+func zeros(v []float64) int {
+	count := 0
+
+	for _, x := range v {
+		if x == 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+var _ = Describe("Dropout", func() {
+	var net Network
+	var inputs, desired la.Matrix
+
+	BeforeEach(func() {
+		net, _ = NewNetwork([]int{20, 100, 5})
+		inputs = la.RandMatrix(20, 1)
+		desired = la.RandMatrix(5, 1)
+	})
+
+	Describe("#Prop", func() {
+		It("never drops units", func() {
+			input := la.RandVector(20)
+			expected, _ := net.Prop(input, Sigmoid)
+
+			net.Dropout = NewDropout(1, 0.1)
+
+			Expect(net.Prop(input, Sigmoid)).To(Equal(expected))
+		})
+	})
+
+	Describe("#Saturate", func() {
+		It("never drops units", func() {
+			_, expected := net.Saturate(inputs, Sigmoid)
+
+			net.Dropout = NewDropout(1, 0.1)
+			_, activations := net.Saturate(inputs, Sigmoid)
+
+			Expect(activations).To(Equal(expected))
+		})
+	})
+
+	Describe("#MBackProp", func() {
+		It("passes no error back through dropped units", func() {
+			net.Dropout = NewDropout(1, 0.5)
+			_, nb := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			Expect(zeros(nb[0])).To(BeNumerically(`>`, 30))
+			Expect(zeros(nb[0])).To(BeNumerically(`<`, 70))
+			Expect(zeros(nb[1])).To(Equal(0))
+		})
+
+		It("is deterministic for a given seed", func() {
+			net.Dropout = NewDropout(7, 0.5)
+			nw, nb := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			net.Dropout = NewDropout(7, 0.5)
+			nw2, nb2 := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			Expect(nw2).To(Equal(nw))
+			Expect(nb2).To(Equal(nb))
+		})
+
+		It("changes nothing when every unit is kept", func() {
+			nw, nb := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			net.Dropout = NewDropout(7, 1)
+			nw2, nb2 := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			Expect(nw2).To(Equal(nw))
+			Expect(nb2).To(Equal(nb))
+		})
+	})
+
+	Describe("#BackProp", func() {
+		It("passes no error back through dropped units", func() {
+			net.Dropout = NewDropout(1, 0.5)
+			_, nb := net.BackProp(randEx(20, 5), Sigmoid, Quadratic)
+
+			Expect(zeros(nb[0])).To(BeNumerically(`>`, 30))
+			Expect(zeros(nb[0])).To(BeNumerically(`<`, 70))
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("still lowers the cost of the network", func() {
+			examples := generateExamples(500)
+			net, _ = NewNetwork([]int{16, 30, 4})
+			net.Dropout = NewDropout(3, 0.8)
+
+			sgd := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net}
+
+			_, origCost := Evaluate(sgd, examples, binaryMatcher{})
+			sgd.MRun(examples, 10, 10)
+			_, newCost := Evaluate(sgd, examples, binaryMatcher{})
+
+			Expect(newCost).To(BeNumerically(`<`, origCost))
+		})
+	})
+})
@@ -0,0 +1,153 @@
+package nn
+
+import (
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// Monitor picks the result of an evaluation EarlyStopping watches
+type Monitor int
+
+const (
+	// MonitorCost stops when the cost stops decreasing
+	MonitorCost Monitor = iota
+	// MonitorAccuracy stops when the accuracy stops increasing
+	MonitorAccuracy
+)
+
+// EarlyStopping is a Callback that stops training once Patience
+// evaluations go by without the monitored result improving on the
+// best seen by more than MinDelta. With RestoreBest the network is
+// set back to the weights of the best evaluation when training ends,
+// even if another callback stopped it.
+// It needs an SGD Evaluation to be set.
+type EarlyStopping struct {
+	Monitor     Monitor
+	Patience    int
+	MinDelta    float64
+	RestoreBest bool
+	// BestEpoch is the epoch of the best evaluation
+	// and StoppedEpoch the epoch training was stopped in,
+	// -1 if training hasn't been stopped
+	BestEpoch    int
+	StoppedEpoch int
+	best         float64
+	wait         int
+	observed     bool
+	bestW        []la.Matrix
+	bestB        [][]float64
+}
+
+func NewEarlyStopping(patience int, minDelta float64) *EarlyStopping {
+	return &EarlyStopping{Patience: patience, MinDelta: minDelta, StoppedEpoch: -1}
+}
+
+// score is the monitored result, larger is always better
+func (es *EarlyStopping) score(s *TrainingState) float64 {
+	if es.Monitor == MonitorAccuracy {
+		return s.Accuracy()
+	}
+
+	return -s.Cost
+}
+
+func (es *EarlyStopping) EpochStart(s *TrainingState) {}
+
+func (es *EarlyStopping) BatchEnd(s *TrainingState) {}
+
+func (es *EarlyStopping) Evaluated(s *TrainingState) {
+	score := es.score(s)
+
+	if !es.observed || score > es.best+es.MinDelta {
+		es.observed = true
+		es.best = score
+		es.BestEpoch = s.Epoch
+		es.wait = 0
+
+		if es.RestoreBest {
+			es.bestW, es.bestB = snapshot(s.Net, es.bestW, es.bestB)
+		}
+
+		return
+	}
+
+	es.wait++
+
+	if es.wait >= es.Patience {
+		es.StoppedEpoch = s.Epoch
+		s.Stop = true
+	}
+}
+
+func (es *EarlyStopping) EpochEnd(s *TrainingState) {}
+
+// TrainEnd restores the best weights
+func (es *EarlyStopping) TrainEnd(s *TrainingState) {
+	if es.RestoreBest && es.bestW != nil {
+		restore(s.Net, es.bestW, es.bestB)
+	}
+}
+
+// State is the progress of the EarlyStopping followed by
+// the best weights and biases kept for RestoreBest
+func (es *EarlyStopping) State() [][]float64 {
+	progress := []float64{
+		es.best,
+		float64(es.wait),
+		boolFloat(es.observed),
+		float64(es.BestEpoch),
+		float64(es.StoppedEpoch),
+	}
+
+	return append([][]float64{progress}, buffers{es.bestW, es.bestB}.state()...)
+}
+
+func (es *EarlyStopping) SetState(n Network, state [][]float64) error {
+	if len(state) == 0 || len(state[0]) != 5 {
+		return ErrMismatchedState
+	}
+
+	s := state[0]
+	es.best, es.wait, es.observed = s[0], int(s[1]), s[2] == 1
+	es.BestEpoch, es.StoppedEpoch = int(s[3]), int(s[4])
+	es.bestW, es.bestB = nil, nil
+
+	if len(state) == 1 {
+		return nil
+	}
+
+	var best buffers
+	rest, e := best.setState(n, state[1:])
+
+	if e != nil || len(rest) != 0 {
+		return ErrMismatchedState
+	}
+
+	es.bestW, es.bestB = best.W, best.B
+	return nil
+}
+
+// snapshot copies the parameters of the network into ws and bs,
+// allocating them if they don't fit
+func snapshot(n Network, ws []la.Matrix, bs [][]float64) ([]la.Matrix, [][]float64) {
+	if len(ws) != len(n.Weights) {
+		c := n.Clone()
+		return c.Weights, c.Biases
+	}
+
+	restore(Network{Weights: ws, Biases: bs}, n.Weights, n.Biases)
+	return ws, bs
+}
+
+// restore copies ws and bs into the parameters of the network in place
+// so every copy of the Network sees the restored values
+func restore(n Network, ws []la.Matrix, bs [][]float64) {
+	for k, w := range ws {
+		for i := 0; i < w.Shape()[0]; i++ {
+			for j := 0; j < w.Shape()[1]; j++ {
+				*n.Weights[k].At(i, j) = *w.At(i, j)
+			}
+		}
+
+		copy(n.Biases[k], bs[k])
+	}
+}
@@ -0,0 +1,157 @@
+package nn_test
+
+import (
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EarlyStopping", func() {
+	// evaluates a sequence of costs, returning the epoch stopped in
+	evaluate := func(es *EarlyStopping, costs ...float64) int {
+		net, _ := NewNetwork([]int{2, 2})
+
+		for i, c := range costs {
+			s := &TrainingState{Epoch: i, Epochs: len(costs), Cost: c, Correct: int(c), Total: 10, Net: net}
+			es.Evaluated(s)
+
+			if s.Stop {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	It("stops once the cost hasn't improved for patience evaluations", func() {
+		es := NewEarlyStopping(2, 0)
+
+		Expect(evaluate(es, 5, 4, 4.5, 3, 3.5, 3.2, 1)).To(Equal(5))
+		Expect(es.BestEpoch).To(Equal(3))
+		Expect(es.StoppedEpoch).To(Equal(5))
+	})
+
+	It("only counts improvements larger than the min delta", func() {
+		es := NewEarlyStopping(2, 0.5)
+
+		Expect(evaluate(es, 5, 4.9, 4.8, 1)).To(Equal(2))
+		Expect(es.BestEpoch).To(Equal(0))
+	})
+
+	It("can monitor the accuracy", func() {
+		es := NewEarlyStopping(1, 0)
+		es.Monitor = MonitorAccuracy
+
+		// Correct follows the given values
+		Expect(evaluate(es, 2, 5, 7, 6)).To(Equal(3))
+		Expect(es.BestEpoch).To(Equal(2))
+	})
+
+	Describe("#MRun", func() {
+		var sgd SGD
+		var examples []Example
+
+		BeforeEach(func() {
+			net, _ := NewNetwork([]int{16, 4})
+			examples = generateExamples(100)
+			sgd = SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net, Seed: 1}
+		})
+
+		It("stops training when the validation cost stalls", func() {
+			epochs := 0
+			es := NewEarlyStopping(2, 0)
+
+			// no learning so the cost never improves
+			sgd.Eta = 0
+			sgd.Evaluation = &Evaluation{Split: 0.2, Matcher: binaryMatcher{}}
+			sgd.Callbacks = []Callback{es, CallbackFuncs{
+				OnEpochEnd: func(s *TrainingState) { epochs++ },
+			}}
+
+			Expect(sgd.MRun(examples, 10, 10)).To(Succeed())
+			Expect(epochs).To(Equal(3))
+			Expect(es.StoppedEpoch).To(Equal(2))
+		})
+
+		It("restores the weights of the best evaluation", func() {
+			var best []float64
+			es := NewEarlyStopping(1, 0)
+			es.RestoreBest = true
+
+			// the first bit of every number below 8 is 0
+			var evaluation []Example
+
+			for i := 0; i < 20; i++ {
+				evaluation = append(evaluation, testEx{i % 8})
+			}
+
+			sgd.Eta = 0
+			sgd.Evaluation = &Evaluation{Data: evaluation, Matcher: binaryMatcher{}}
+			sgd.Callbacks = []Callback{es, CallbackFuncs{
+				OnEvaluated: func(s *TrainingState) {
+					if s.Epoch == 0 {
+						best = rows(s.Net.Weights[0])
+					}
+				},
+				// saturate the first output after the first epoch
+				OnBatchEnd: func(s *TrainingState) {
+					if s.Epoch > 0 {
+						*s.Net.Weights[0].At(0, 0) += 10
+						s.Net.Biases[0][0] += 10
+					}
+				},
+			}}
+
+			sgd.MRun(examples, 4, 10)
+
+			Expect(es.BestEpoch).To(Equal(0))
+			Expect(es.StoppedEpoch).To(Equal(1))
+			Expect(rows(sgd.Net.Weights[0])).To(Equal(best))
+		})
+
+		It("restores the best weights when a later callback stops training", func() {
+			var best []float64
+			es := NewEarlyStopping(5, 0)
+			es.RestoreBest = true
+
+			sgd.Evaluation = &Evaluation{Data: examples, Matcher: binaryMatcher{}}
+			sgd.Callbacks = []Callback{es, CallbackFuncs{
+				OnEvaluated: func(s *TrainingState) {
+					if s.Epoch == es.BestEpoch {
+						best = rows(s.Net.Weights[0])
+					}
+				},
+				// make the last epoch the worst and stop after it
+				OnBatchEnd: func(s *TrainingState) {
+					if s.Epoch == 2 {
+						*s.Net.Weights[0].At(0, 0) += 10
+						s.Net.Biases[0][0] += 10
+					}
+				},
+				OnEpochEnd: func(s *TrainingState) { s.Stop = s.Epoch == 2 },
+			}}
+
+			Expect(sgd.MRun(examples, 10, 10)).To(Succeed())
+
+			Expect(es.BestEpoch).To(BeNumerically(`<`, 2))
+			Expect(es.StoppedEpoch).To(Equal(-1))
+			Expect(rows(sgd.Net.Weights[0])).To(Equal(best))
+		})
+	})
+
+	Describe("SplitValidation", func() {
+		It("holds out the fraction of the examples chosen by the seed", func() {
+			examples := generateExamples(50)
+
+			training, validation := SplitValidation(examples, 0.2, 4)
+
+			Expect(training).To(HaveLen(40))
+			Expect(validation).To(HaveLen(10))
+			Expect(append(training, validation...)).To(ConsistOf(examples))
+
+			again, _ := SplitValidation(examples, 0.2, 4)
+			Expect(again).To(Equal(training))
+		})
+	})
+})
@@ -0,0 +1,8 @@
+package nn
+
+// unexported helpers used by the tests of package nn_test
+var (
+	// CentralDifference is the finite difference GradCheck takes
+	CentralDifference = centralDifference
+	Shuffle           = shuffle
+)
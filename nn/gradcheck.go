@@ -0,0 +1,173 @@
+package nn
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// the step used for the central finite difference
+const gradCheckStep = 1e-5
+
+// LayerError is the largest relative error between an analytic and
+// numerical gradient over the weights and biases of a single layer
+type LayerError struct {
+	Weights float64
+	Biases  float64
+}
+
+// Max is the larger of the weight and bias errors
+func (l LayerError) Max() float64 {
+	return math.Max(l.Weights, l.Biases)
+}
+
+// GradCheckResult holds the LayerError of every layer for the
+// gradients computed by BackProp (averaged over the examples)
+// and MBackProp (over the examples as a single mini batch)
+type GradCheckResult struct {
+	BackProp  []LayerError
+	MBackProp []LayerError
+}
+
+// Max is the largest error of any layer from either method.
+// Correct gradients are usually well below 1e-6.
+func (r GradCheckResult) Max() float64 {
+	max := 0.0
+
+	for _, errs := range [][]LayerError{r.BackProp, r.MBackProp} {
+		for _, l := range errs {
+			max = math.Max(max, l.Max())
+		}
+	}
+
+	return max
+}
+
+// GradCheck perturbs every weight and bias of the network by a small
+// step in each direction and compares the change in the average cost
+// over the examples to the gradients from BackProp and MBackProp. The
+// network is restored before returning and dropout is never applied.
+// Every parameter needs two passes over the examples so this is meant
+// for small networks and a handful of examples.
+func GradCheck(n Network, examples []Example, a, c Differentiable) GradCheckResult {
+	n.Dropout = nil
+
+	numW, numB := numericalGradient(n, examples, a, c)
+
+	bpW := make([]la.Matrix, len(n.Weights))
+	bpB := make([][]float64, len(n.Biases))
+
+	for k := range n.Weights {
+		bpW[k] = la.ZeroMatrix(n.Weights[k].Shape()[0], n.Weights[k].Shape()[1])
+		bpB[k] = make([]float64, len(n.Biases[k]))
+	}
+
+	for _, e := range examples {
+		nablaW, nablaB := n.BackProp(e, a, c)
+
+		for k := range nablaW {
+			bpW[k] = la.MSUM(bpW[k], nablaW[k])
+			bpB[k] = la.VSUM(bpB[k], nablaB[k])
+		}
+	}
+
+	for k := range bpW {
+		bpW[k] = la.MSCALE(bpW[k], 1/float64(len(examples)))
+		bpB[k] = la.VSCALE(bpB[k], 1/float64(len(examples)))
+	}
+
+	input, desired := miniBatchToMatricies(examples)
+	mW, mB := n.MBackProp(input, desired, a, c)
+
+	return GradCheckResult{
+		BackProp:  layerErrors(bpW, bpB, numW, numB),
+		MBackProp: layerErrors(mW, mB, numW, numB),
+	}
+}
+
+// numericalGradient estimates the gradient of the average cost with
+// respect to every weight and bias with a central difference
+func numericalGradient(n Network, examples []Example, a, c Differentiable) ([]la.Matrix, [][]float64) {
+	nablaW := make([]la.Matrix, len(n.Weights))
+	nablaB := make([][]float64, len(n.Biases))
+
+	for k, w := range n.Weights {
+		rows, cols := w.Shape()[0], w.Shape()[1]
+		nablaW[k] = la.ZeroMatrix(rows, cols)
+		nablaB[k] = make([]float64, len(n.Biases[k]))
+
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				*nablaW[k].At(i, j) = centralDifference(w.At(i, j), func() float64 {
+					return averageCost(n, examples, a, c)
+				})
+			}
+		}
+
+		for i := range n.Biases[k] {
+			nablaB[k][i] = centralDifference(&n.Biases[k][i], func() float64 {
+				return averageCost(n, examples, a, c)
+			})
+		}
+	}
+
+	return nablaW, nablaB
+}
+
+// (cost(x + h) - cost(x - h)) / 2h leaving x as it was
+func centralDifference(x *float64, cost func() float64) float64 {
+	orig := *x
+
+	*x = orig + gradCheckStep
+	plus := cost()
+	*x = orig - gradCheckStep
+	minus := cost()
+	*x = orig
+
+	return (plus - minus) / (2 * gradCheckStep)
+}
+
+func averageCost(n Network, examples []Example, a, c Differentiable) float64 {
+	outputActivation := n.activation(len(n.Weights)-1, a)
+	total := 0.0
+
+	for _, e := range examples {
+		z, actual := n.forward(e.GetInput(), a)
+		total += exampleCost(z, actual, e.GetOutput(), outputActivation, c)
+	}
+
+	return total / float64(len(examples))
+}
+
+func layerErrors(w []la.Matrix, b [][]float64, numW []la.Matrix, numB [][]float64) []LayerError {
+	out := make([]LayerError, len(w))
+
+	for k := range w {
+		for i := 0; i < w[k].Shape()[0]; i++ {
+			for j := 0; j < w[k].Shape()[1]; j++ {
+				out[k].Weights = math.Max(out[k].Weights, relativeError(*w[k].At(i, j), *numW[k].At(i, j)))
+			}
+		}
+
+		for i := range b[k] {
+			out[k].Biases = math.Max(out[k].Biases, relativeError(b[k][i], numB[k][i]))
+		}
+	}
+
+	return out
+}
+
+// the error of a correct gradient from the finite difference is about
+// step^2 relative to its size but never much below gradCheckATol,
+// the rounding of the cost over 2 * step
+const (
+	gradCheckRTol = 1e-6
+	gradCheckATol = 1e-9
+)
+
+// |a - b| / (max(|a|, |b|) + atol / rtol) which is below rtol exactly
+// when |a - b| <= atol + rtol * max(|a|, |b|), so gradients near zero
+// are judged by their absolute difference instead
+func relativeError(a, b float64) float64 {
+	return math.Abs(a-b) / (math.Max(math.Abs(a), math.Abs(b)) + gradCheckATol/gradCheckRTol)
+}
@@ -0,0 +1,87 @@
+package nn_test
+
+import (
+	"fmt"
+	"math/rand"
+
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GradCheck", func() {
+	// random networks and examples, correct gradients pass for every seed
+	for seed := int64(1); seed <= 20; seed++ {
+		seed := seed
+
+		Context(fmt.Sprintf("Given seed %d", seed), func() {
+			gradCheckSpecs(seed)
+		})
+	}
+})
+
+func gradCheckSpecs(seed int64) {
+	var net Network
+	var examples []Example
+
+	BeforeEach(func() {
+		r := rand.New(rand.NewSource(seed))
+		net, _ = NewNetworkInit(r, []int{4, 5, 3})
+		examples = make([]Example, 4)
+
+		for i := range examples {
+			examples[i] = fixedEx{
+				randValues(r, 4),
+				Softmax.VFn(randValues(r, 3)),
+			}
+		}
+	})
+
+	It("reports a small error for every layer of a correct network", func() {
+		net.Activations = []Differentiable{Tanh, Softmax}
+
+		result := GradCheck(net, examples, Sigmoid, CategoricalCrossEntropy)
+
+		Expect(result.BackProp).To(HaveLen(2))
+		Expect(result.MBackProp).To(HaveLen(2))
+		Expect(result.Max()).To(BeNumerically(`<`, 1e-6))
+	})
+
+	It("leaves the network unchanged", func() {
+		weights := rows(net.Weights[0])
+		biases := append([]float64{}, net.Biases[1]...)
+
+		GradCheck(net, examples, Sigmoid, Quadratic)
+
+		Expect(rows(net.Weights[0])).To(Equal(weights))
+		Expect(net.Biases[1]).To(Equal(biases))
+	})
+
+	It("ignores dropout", func() {
+		net.Dropout = NewDropout(1, 0.5)
+
+		Expect(GradCheck(net, examples, Sigmoid, Quadratic).Max()).To(BeNumerically(`<`, 1e-6))
+	})
+
+	It("finds the layers with an incorrect derivative", func() {
+		net.Activations = []Differentiable{wrongPrime{}, nil}
+
+		result := GradCheck(net, examples, Sigmoid, Quadratic)
+
+		Expect(result.BackProp[0].Weights).To(BeNumerically(`>`, 1e-2))
+		Expect(result.MBackProp[0].Biases).To(BeNumerically(`>`, 1e-2))
+		Expect(result.BackProp[1].Max()).To(BeNumerically(`<`, 1e-6))
+	})
+}
+
+// tanh with the derivative of the sigmoid
+type wrongPrime struct{}
+
+func (w wrongPrime) Fn(zs ...float64) float64 {
+	return Tanh.Fn(zs...)
+}
+
+func (w wrongPrime) Prime(zs ...float64) float64 {
+	return Sigmoid.Prime(zs...)
+}
@@ -0,0 +1,179 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// A WeightInit creates the fanOut x fanIn weights of a layer with
+// fanIn inputs and fanOut units drawing from r, the global source if nil
+type WeightInit func(r *rand.Rand, fanOut, fanIn int) la.Matrix
+
+// A BiasInit creates the biases of a layer of n units drawing from r
+type BiasInit func(r *rand.Rand, n int) []float64
+
+// Init is how the weights and biases of a single layer start out.
+// Nil fields use the defaults of NewNetwork.
+type Init struct {
+	Weights WeightInit
+	Biases  BiasInit
+}
+
+// UniformInit uses the same Init for every layer
+func UniformInit(numLayers int, i Init) []Init {
+	out := make([]Init, numLayers)
+
+	for k := range out {
+		out[k] = i
+	}
+
+	return out
+}
+
+func (i Init) weights(r *rand.Rand, fanOut, fanIn int) la.Matrix {
+	if i.Weights == nil {
+		return SquashedWeights(r, fanOut, fanIn)
+	}
+
+	return i.Weights(r, fanOut, fanIn)
+}
+
+func (i Init) biases(r *rand.Rand, n int) []float64 {
+	if i.Biases == nil {
+		return RandBiases(r, n)
+	}
+
+	return i.Biases(r, n)
+}
+
+// fromData creates a row major fanOut x fanIn matrix holding data
+func fromData(fanOut, fanIn int, data []float64) la.Matrix {
+	m := la.ZeroMatrix(fanOut, fanIn)
+	copy(m.Data(), data)
+	return m
+}
+
+func uniformWeights(limit func(fanOut, fanIn float64) float64) WeightInit {
+	return func(r *rand.Rand, fanOut, fanIn int) la.Matrix {
+		l := limit(float64(fanOut), float64(fanIn))
+		return fromData(fanOut, fanIn, la.UniformVectorR(r, fanOut*fanIn, l))
+	}
+}
+
+func normalWeights(std func(fanOut, fanIn float64) float64) WeightInit {
+	return func(r *rand.Rand, fanOut, fanIn int) la.Matrix {
+		s := std(float64(fanOut), float64(fanIn))
+		return fromData(fanOut, fanIn, la.NormalVectorR(r, fanOut*fanIn, s))
+	}
+}
+
+// SquashedWeights is uniform on [-1, 1] scaled by 1 / sqrt(fanIn),
+// the default of NewNetwork
+func SquashedWeights(r *rand.Rand, fanOut, fanIn int) la.Matrix {
+	return la.RandMatrixSquashedR(r, fanOut, fanIn)
+}
+
+// XavierUniform (Glorot) keeps the variance of activations and
+// gradients even for sigmoid and tanh layers,
+// U(-sqrt(6 / (fanIn + fanOut)), sqrt(6 / (fanIn + fanOut)))
+var XavierUniform = uniformWeights(func(fanOut, fanIn float64) float64 {
+	return math.Sqrt(6 / (fanIn + fanOut))
+})
+
+// XavierNormal is N(0, 2 / (fanIn + fanOut))
+var XavierNormal = normalWeights(func(fanOut, fanIn float64) float64 {
+	return math.Sqrt(2 / (fanIn + fanOut))
+})
+
+// HeUniform (Kaiming) makes up for ReLUs zeroing half their
+// inputs, U(-sqrt(6 / fanIn), sqrt(6 / fanIn))
+var HeUniform = uniformWeights(func(_, fanIn float64) float64 {
+	return math.Sqrt(6 / fanIn)
+})
+
+// HeNormal is N(0, 2 / fanIn)
+var HeNormal = normalWeights(func(_, fanIn float64) float64 {
+	return math.Sqrt(2 / fanIn)
+})
+
+// LeCunUniform keeps the variance of activations for linear and
+// self normalizing layers, U(-sqrt(3 / fanIn), sqrt(3 / fanIn))
+var LeCunUniform = uniformWeights(func(_, fanIn float64) float64 {
+	return math.Sqrt(3 / fanIn)
+})
+
+// LeCunNormal is N(0, 1 / fanIn)
+var LeCunNormal = normalWeights(func(_, fanIn float64) float64 {
+	return math.Sqrt(1 / fanIn)
+})
+
+// Orthogonal creates weights whose rows (or columns, whichever there
+// are fewer of) are orthonormal and then scaled by gain, found by
+// Gram-Schmidt on normally distributed weights
+func Orthogonal(gain float64) WeightInit {
+	return func(r *rand.Rand, fanOut, fanIn int) la.Matrix {
+		// orthonormalize the fewer, longer vectors
+		n, length := fanOut, fanIn
+
+		if fanOut > fanIn {
+			n, length = fanIn, fanOut
+		}
+
+		vs := make([][]float64, n)
+
+		for i := range vs {
+			vs[i] = orthonormal(r, vs[:i], length)
+		}
+
+		m := la.ZeroMatrix(fanOut, fanIn)
+
+		for i, v := range vs {
+			for j, x := range v {
+				if fanOut > fanIn {
+					*m.At(j, i) = gain * x
+				} else {
+					*m.At(i, j) = gain * x
+				}
+			}
+		}
+
+		return m
+	}
+}
+
+// orthonormal returns a random unit vector orthogonal to every one of
+// vs, redrawing in the unlikely case the draw is (nearly) in their span
+func orthonormal(r *rand.Rand, vs [][]float64, length int) []float64 {
+	for {
+		v := la.NormalVectorR(r, length, 1)
+
+		// modified Gram-Schmidt removes each projection in turn
+		for _, u := range vs {
+			la.Axpy(-la.Dot(u, v), u, v)
+		}
+
+		if norm := math.Sqrt(la.Dot(v, v)); norm > 1e-6 {
+			return la.VSCALE(v, 1/norm)
+		}
+	}
+}
+
+// RandBiases are uniform on [-1, 1], the default of NewNetwork
+func RandBiases(r *rand.Rand, n int) []float64 {
+	return la.RandVectorR(r, n)
+}
+
+// ZeroBiases start every bias at 0
+func ZeroBiases(_ *rand.Rand, n int) []float64 {
+	return make([]float64, n)
+}
+
+// ConstantBiases start every bias at c, a small positive value
+// keeps ReLUs active at the start of training
+func ConstantBiases(c float64) BiasInit {
+	return func(_ *rand.Rand, n int) []float64 {
+		return la.Map(make([]float64, n), la.Add(c))
+	}
+}
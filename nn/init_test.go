@@ -0,0 +1,129 @@
+package nn_test
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+// moments returns the mean and standard deviation of xs
+func moments(xs []float64) (mean, std float64) {
+	for _, x := range xs {
+		mean += x
+	}
+
+	mean /= float64(len(xs))
+
+	for _, x := range xs {
+		std += (x - mean) * (x - mean)
+	}
+
+	return mean, math.Sqrt(std / float64(len(xs)))
+}
+
+var _ = Describe("Init", func() {
+	const fanOut, fanIn = 300, 200
+
+	table.DescribeTable("draws weights with the variance of the scheme",
+		func(init WeightInit, std, limit float64) {
+			w := init(nil, fanOut, fanIn)
+			mean, actual := moments(w.Data())
+
+			Expect(w.Shape()).To(Equal([]int{fanOut, fanIn}))
+			Expect(mean).To(BeNumerically(`~`, 0, 0.05*std))
+			Expect(actual).To(BeNumerically(`~`, std, 0.05*std))
+
+			for _, x := range w.Data() {
+				Expect(math.Abs(x)).To(BeNumerically(`<=`, limit))
+			}
+		},
+		// a uniform on [-l, l] has a standard deviation of l / sqrt(3)
+		table.Entry(`Xavier uniform`, XavierUniform, math.Sqrt(2.0/(fanIn+fanOut)), math.Sqrt(6.0/(fanIn+fanOut))),
+		table.Entry(`Xavier normal`, XavierNormal, math.Sqrt(2.0/(fanIn+fanOut)), math.Inf(1)),
+		table.Entry(`He uniform`, HeUniform, math.Sqrt(2.0/fanIn), math.Sqrt(6.0/fanIn)),
+		table.Entry(`He normal`, HeNormal, math.Sqrt(2.0/fanIn), math.Inf(1)),
+		table.Entry(`LeCun uniform`, LeCunUniform, math.Sqrt(1.0/fanIn), math.Sqrt(3.0/fanIn)),
+		table.Entry(`LeCun normal`, LeCunNormal, math.Sqrt(1.0/fanIn), math.Inf(1)),
+		table.Entry(`Squashed`, WeightInit(SquashedWeights), math.Sqrt(1.0/(3*fanIn)), math.Sqrt(1.0/fanIn)),
+	)
+
+	It("creates orthogonal weights of either orientation", func() {
+		for _, shape := range [][]int{{20, 30}, {30, 20}, {25, 25}} {
+			w := Orthogonal(2)(nil, shape[0], shape[1])
+
+			// W Wt or Wt W, whichever is smaller, is gain^2 I
+			product := la.MMDot(w, w.T())
+
+			if shape[0] > shape[1] {
+				product = la.MMDot(w.T(), w)
+			}
+
+			for i := 0; i < product.Shape()[0]; i++ {
+				for j := 0; j < product.Shape()[1]; j++ {
+					expected := 0.0
+
+					if i == j {
+						expected = 4
+					}
+
+					Expect(*product.At(i, j)).To(BeNumerically(`~`, expected, 1e-9))
+				}
+			}
+		}
+	})
+
+	It("creates zero and constant biases", func() {
+		Expect(ZeroBiases(nil, 3)).To(Equal([]float64{0, 0, 0}))
+		Expect(ConstantBiases(0.1)(nil, 2)).To(Equal([]float64{0.1, 0.1}))
+	})
+
+	Describe("NewNetworkInit", func() {
+		It("initializes every layer with its own Init", func() {
+			net, e := NewNetworkInit(nil, []int{4, 3, 2, 5},
+				Init{Weights: Orthogonal(1), Biases: ZeroBiases},
+				Init{Biases: ConstantBiases(0.5)},
+			)
+
+			Expect(e).ToNot(HaveOccurred())
+			Expect(net.Biases[0]).To(Equal([]float64{0, 0, 0}))
+			Expect(net.Biases[1]).To(Equal([]float64{0.5, 0.5}))
+
+			// the default biases are random
+			Expect(net.Biases[2]).ToNot(Equal(make([]float64, 5)))
+
+			for _, w := range net.Weights {
+				for _, x := range w.Data() {
+					Expect(math.Abs(x)).To(BeNumerically(`<=`, 1))
+				}
+			}
+		})
+
+		It("keeps the signal of deep ReLU and tanh networks alive", func() {
+			layers := []int{100, 100, 100, 100, 100, 100, 100, 100, 100}
+			input := la.NormalVector(100, 1)
+
+			// the output of a layer past every hidden layer
+			outputStd := func(a Differentiable, inits ...Init) float64 {
+				net, _ := NewNetworkInit(nil, layers, inits...)
+				out, _ := net.Prop(input, a)
+				_, std := moments(out)
+				return std
+			}
+
+			he := UniformInit(8, Init{Weights: HeNormal, Biases: ZeroBiases})
+			xavier := UniformInit(8, Init{Weights: XavierNormal, Biases: ZeroBiases})
+			squashed := UniformInit(8, Init{Biases: ZeroBiases})
+
+			Expect(outputStd(ReLU, he...)).To(BeNumerically(`>`, 0.3))
+			Expect(outputStd(Tanh, xavier...)).To(BeNumerically(`>`, 0.1))
+
+			// without the factor of 2 ReLUs halve the signal every layer
+			Expect(outputStd(ReLU, squashed...)).To(BeNumerically(`<`, 0.1))
+		})
+	})
+})
@@ -2,10 +2,14 @@ package nn
 
 import (
 	"errors"
+	"math"
+	"math/rand"
 
 	"github.com/hayden-erickson/neural-network/la"
 )
 
+var ErrLayerSize = errors.New(`Every layer must have at least one unit`)
+
 type Example interface {
 	GetInput() []float64
 	GetOutput() []float64
@@ -14,23 +18,64 @@ type Example interface {
 type Network struct {
 	Weights []la.Matrix
 	Biases  [][]float64
+	// Activations[i] is applied to the output of Weights[i]. Layers
+	// past the end or set to nil use the activation passed to Prop,
+	// Saturate, BackProp or MBackProp.
+	Activations []Differentiable
+	// Dropout is only applied while training, nil disables it
+	Dropout *Dropout
+}
+
+// activation returns the activation of layer i falling back to a
+func (n Network) activation(i int, a Differentiable) Differentiable {
+	if i < len(n.Activations) && n.Activations[i] != nil {
+		return n.Activations[i]
+	}
+
+	return a
 }
 
 func getZ(a, b []float64, w la.Matrix) []float64 {
 	return la.VSUM(la.MVDot(w, a), b)
 }
 
-func (n Network) Prop(input []float64, aFunc Differentiable) []float64 {
-	a := la.CreateVMapper(ToOP(aFunc.Fn))
-	activation := input
+// Prop returns the output of the network for the input, or a
+// *la.ShapeError if it isn't the size of the first layer
+func (n Network) Prop(input []float64, aFunc Differentiable) ([]float64, error) {
+	if e := n.checkInput(input); e != nil {
+		return nil, e
+	}
+
+	_, activation := n.forward(input, aFunc)
+	return activation, nil
+}
+
+// checkInput returns a *la.ShapeError if the input doesn't
+// have a value for every column of the first weights, or if
+// there are no weights at all
+func (n Network) checkInput(input []float64) error {
+	if len(n.Weights) == 0 {
+		return &la.ShapeError{Op: `Prop`, A: []int{}, B: []int{len(input)}}
+	}
+
+	if _, cols := la.Dims(n.Weights[0]); cols != len(input) {
+		return &la.ShapeError{Op: `Prop`, A: n.Weights[0].Shape(), B: []int{len(input)}}
+	}
+
+	return nil
+}
+
+// forward returns the weighted input and activation of the output layer
+func (n Network) forward(input []float64, aFunc Differentiable) (z, activation []float64) {
+	activation = input
 
 	// sigmoid(wa + b)
 	for i := 0; i < len(n.Weights); i++ {
-		activation =
-			a(la.VSUM(la.MVDot(n.Weights[i], activation), n.Biases[i]))
+		z = la.VSUM(la.MVDot(n.Weights[i], activation), n.Biases[i])
+		activation = activate(n.activation(i, aFunc), z)
 	}
 
-	return activation
+	return z, activation
 }
 
 func (n Network) BackProp(
@@ -40,23 +85,29 @@ func (n Network) BackProp(
 ) ([]la.Matrix, [][]float64) {
 	nablaB := make([][]float64, len(n.Biases))
 	nablaW := make([]la.Matrix, len(n.Weights))
-	a := la.CreateVMapper(ToOP(activation.Fn))
-	aPrime := la.CreateVMapper(ToOP(activation.Prime))
-	cPrime := la.CreateVectorOP(ToBOP(cost.Prime))
 
 	activations := [][]float64{e.GetInput()}
 	var zs [][]float64
+	masks := make([][]float64, len(n.Weights))
 
 	for i := 0; i < len(n.Weights); i++ {
 		z := getZ(activations[i], n.Biases[i], n.Weights[i])
 		zs = append(zs, z)
-		activations = append(activations, a(z))
+		out := activate(n.activation(i, activation), z)
+
+		if i < len(n.Weights)-1 {
+			if m := n.Dropout.mask(i, len(z), 1); m != nil {
+				masks[i] = m.Data()
+				out = la.VMULT(out, masks[i])
+			}
+		}
+
+		activations = append(activations, out)
 	}
 
 	actual := activations[len(activations)-1]
-	desired := e.GetOutput()
-
-	delta := la.VMULT(cPrime(actual, desired), aPrime(zs[len(zs)-1]))
+	outputActivation := n.activation(len(zs)-1, activation)
+	delta := outputDelta(actual, e.GetOutput(), zs[len(zs)-1], outputActivation, cost)
 
 	nablaB[len(nablaB)-1] = delta
 	nablaW[len(nablaW)-1] = la.Outer(delta, activations[len(activations)-2])
@@ -64,10 +115,15 @@ func (n Network) BackProp(
 	numLayers := len(n.Weights) + 1
 
 	for l := 2; l < numLayers; l++ {
-		ap := aPrime(zs[len(zs)-l])
 		w := n.Weights[(len(n.Weights)-l)+1]
+		grad := la.MVDot(w.T(), delta)
+
+		// dropped units pass no error back
+		if masks[len(zs)-l] != nil {
+			grad = la.VMULT(grad, masks[len(zs)-l])
+		}
 
-		delta = la.VMULT(la.MVDot(w.T(), delta), ap)
+		delta = backward(n.activation(len(zs)-l, activation), zs[len(zs)-l], grad)
 
 		nablaB[len(nablaB)-l] = delta
 		nablaW[len(nablaW)-l] = la.Outer(delta, activations[(numLayers-l)-1])
@@ -77,7 +133,16 @@ func (n Network) BackProp(
 }
 
 func (n Network) Saturate(input la.Matrix, a Differentiable) (weighted, activations []la.Matrix) {
+	weighted, activations, _ = n.saturate(input, a, false)
+	return weighted, activations
+}
+
+// saturate propagates forward, applying the network's dropout to the
+// hidden layers when training. masks[i] holds the dropout mask of
+// layer i or nil if it wasn't dropped.
+func (n Network) saturate(input la.Matrix, a Differentiable, training bool) (weighted, activations, masks []la.Matrix) {
 	activations = []la.Matrix{input}
+	masks = make([]la.Matrix, len(n.Weights))
 
 	// === Propagate forward ===
 	// add activation and z
@@ -87,18 +152,44 @@ func (n Network) Saturate(input la.Matrix, a Differentiable) (weighted, activati
 			la.MapVectorCol(n.Biases[i], la.SUM))
 
 		weighted = append(weighted, z)
-		activations = append(activations, la.MMapD(z, ToOP(a.Fn)))
+		activation := mActivate(n.activation(i, a), z)
+
+		if training && i < len(n.Weights)-1 {
+			masks[i] = n.Dropout.mask(i, z.Shape()[0], z.Shape()[1])
+
+			if masks[i] != nil {
+				activation = la.MMULT(activation, masks[i])
+			}
+		}
+
+		activations = append(activations, activation)
 	}
 
-	return weighted, activations
+	return weighted, activations, masks
 }
 
+// outputDelta is the error of the output layer for a single example
+// given its activation, desired output and weighted input
+func outputDelta(actual, desired, weighted []float64, a, c Differentiable) []float64 {
+	// the activation and cost derivatives cancel to a - y
+	if isSoftmaxCCE(a, c) || isSigmoidCE(a, c) {
+		return la.VSUB(actual, desired)
+	}
+
+	cPrime := la.CreateVectorOP(ToBOP(c.Prime))
+	return backward(a, weighted, cPrime(actual, desired))
+}
+
+// Delta is the error of the output layer, the derivative of the cost c
+// with respect to the weighted input of every example (column)
 func Delta(actual, desired, weighted la.Matrix, a, c Differentiable) la.Matrix {
+	// the activation and cost derivatives cancel to a - y
+	if isSoftmaxCCE(a, c) || isSigmoidCE(a, c) {
+		return la.MAggD(actual, desired, la.SUB)
+	}
+
 	mCPrime := la.CreateMatrixOP(ToBOP(c.Prime))
-	// Quadratic
-	// return la.MMULT(mCPrime(actual, desired), la.MMapD(weighted, ToOP(a.Prime)))
-	// Cross Entropy
-	return mCPrime(actual, desired)
+	return mBackward(a, weighted, mCPrime(actual, desired))
 }
 
 func (n Network) MBackProp(
@@ -107,51 +198,60 @@ func (n Network) MBackProp(
 	a Differentiable,
 	c Differentiable,
 ) (nablaW []la.Matrix, nablaB [][]float64) {
+	nablaW, nablaB, _, _ = n.mBackProp(input, desired, a, c)
+	return nablaW, nablaB
+}
 
-	nablaB = make([][]float64, len(n.Biases))
-	nablaW = make([]la.Matrix, len(n.Weights))
-
-	// === Propagate forward ===
-	zs, activations := n.Saturate(input, a)
-
-	actual := activations[len(activations)-1]
-
-	// === Compute Delta ===
-	// delta := la.MULT(cPrime(actual, desired), aPrime(zs[len(zs)-1]))
-	// mCPrime := la.CreateMatrixOP(ToBOP(c.Prime))
-	// delta := la.MMULT(mCPrime(actual, desired), la.MMapD(zs[len(zs)-1], ToOP(a.Prime)))
-	delta := Delta(actual, desired, zs[len(zs)-1], a, c)
-
-	nablaB[len(nablaB)-1] = la.RowAvg(delta)
-	nablaW[len(nablaW)-1] = la.MOuterColAvg(delta, activations[len(activations)-2])
-
-	numLayers := len(n.Weights) + 1
-
-	// === Back Propagate ===
-	for l := 2; l < numLayers; l++ {
-		ap := la.MMap(zs[len(zs)-l], ToOP(a.Prime))
-		w := n.Weights[(len(n.Weights)-l)+1]
-
-		delta = la.MMULT(la.MMDot(w.T(), delta), ap)
-
-		nablaB[len(nablaB)-l] = la.RowAvg(delta)
-		nablaW[len(nablaW)-l] = la.MOuterColAvg(delta, activations[(numLayers-l)-1])
-	}
+// mBackProp also returns the weighted input and activation of the
+// output layer so the cost of the mini batch can be found without
+// propagating forward again
+func (n Network) mBackProp(
+	input la.Matrix,
+	desired la.Matrix,
+	a Differentiable,
+	c Differentiable,
+) (nablaW []la.Matrix, nablaB [][]float64, weighted, actual la.Matrix) {
+	ws := n.newWorkspace(a, c, input.Shape()[1])
+	ws.activations[0], ws.desired = input, desired
+	n.mBackPropInto(ws, a, c)
 
-	return nablaW, nablaB
+	return ws.nablaW, ws.nablaB, ws.zs[len(ws.zs)-1], ws.activations[len(n.Weights)]
 }
 
+// NewNetwork creates a network with the given number of units in each
+// layer, the first being the input. The weights are uniform on [-1, 1]
+// scaled by 1 / sqrt(fan in) and the biases uniform on [-1, 1].
 func NewNetwork(layers []int) (Network, error) {
+	return NewNetworkInit(nil, layers)
+}
+
+// NewNetworkInit is NewNetwork with the weights and biases of layer i
+// (Weights[i]) created by inits[i]. Layers past the end of inits use
+// the defaults of NewNetwork. Every random value is drawn from r so
+// seeding it reproduces the network, a nil r uses the global source.
+func NewNetworkInit(r *rand.Rand, layers []int, inits ...Init) (Network, error) {
 	if len(layers) <= 1 {
 		return Network{}, errors.New(`Cannot initialize network with less than 2 layers`)
 	}
 
+	for _, size := range layers {
+		if size <= 0 {
+			return Network{}, ErrLayerSize
+		}
+	}
+
 	weights := make([]la.Matrix, len(layers)-1)
 	biases := make([][]float64, len(layers)-1)
 
 	for i := 0; i < len(weights); i++ {
-		weights[i] = la.RandMatrixSquashed(layers[i+1], layers[i])
-		biases[i] = la.RandVector(layers[i+1])
+		var layerInit Init
+
+		if i < len(inits) {
+			layerInit = inits[i]
+		}
+
+		weights[i] = layerInit.weights(r, layers[i+1], layers[i])
+		biases[i] = layerInit.biases(r, layers[i+1])
 	}
 
 	return Network{
@@ -160,15 +260,85 @@ func NewNetwork(layers []int) (Network, error) {
 	}, nil
 }
 
+// CopyNetwork sets into to a deep copy of from
 func CopyNetwork(into, from *Network) {
-	into.Weights = make([]la.Matrix, len(from.Weights))
-	into.Biases = make([][]float64, len(from.Biases))
+	*into = from.Clone()
+}
 
-	for i, _ := range from.Weights {
-		into.Weights[i] = la.ZeroMatrix(from.Weights[i].Shape()[0], from.Weights[i].Shape()[1])
-		into.Biases[i] = make([]float64, len(from.Biases[i]))
+// Clone returns a copy of the network sharing no weights, biases or
+// dropout with it, so training one leaves the other untouched. The
+// activations are shared as they hold no state.
+func (n Network) Clone() Network {
+	out := Network{
+		Weights: make([]la.Matrix, len(n.Weights)),
+		Biases:  make([][]float64, len(n.Biases)),
+	}
+
+	if n.Activations != nil {
+		out.Activations = append([]Differentiable(nil), n.Activations...)
+	}
 
-		into.Weights[i] = from.Weights[i]
-		copy(into.Biases[i], from.Biases[i])
+	if n.Dropout != nil {
+		out.Dropout = n.Dropout.clone()
 	}
+
+	for i, w := range n.Weights {
+		rows, cols := la.Dims(w)
+		out.Weights[i] = la.ZeroMatrix(rows, cols)
+		copyMatrix(out.Weights[i], w)
+	}
+
+	for i, b := range n.Biases {
+		out.Biases[i] = append([]float64(nil), b...)
+	}
+
+	return out
+}
+
+// Equal reports whether both networks have the same shape and
+// exactly the same weights and biases, whatever their layouts
+func (n Network) Equal(o Network) bool {
+	return n.ApproxEqual(o, 0)
+}
+
+// ApproxEqual reports whether both networks have the same shape and
+// every weight and bias within tol of the other's
+func (n Network) ApproxEqual(o Network, tol float64) bool {
+	if len(n.Weights) != len(o.Weights) || len(n.Biases) != len(o.Biases) {
+		return false
+	}
+
+	for k, w := range n.Weights {
+		rows, cols := la.Dims(w)
+
+		if oRows, oCols := la.Dims(o.Weights[k]); rows != oRows || cols != oCols {
+			return false
+		}
+
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				if !within(*w.At(i, j), *o.Weights[k].At(i, j), tol) {
+					return false
+				}
+			}
+		}
+	}
+
+	for k, b := range n.Biases {
+		if len(b) != len(o.Biases[k]) {
+			return false
+		}
+
+		for i, x := range b {
+			if !within(x, o.Biases[k][i], tol) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func within(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
 }
@@ -1,12 +1,16 @@
 package nn_test
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
 
 	"github.com/hayden-erickson/neural-network/la"
 	. "github.com/hayden-erickson/neural-network/nn"
 
 	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -24,6 +28,16 @@ var _ = Describe("Network", func() {
 			})
 		})
 
+		Context("Given a layer without units", func() {
+			It("returns an error", func() {
+				_, err := NewNetwork([]int{4, 0, 2})
+				Expect(err).To(Equal(ErrLayerSize))
+
+				_, err = NewNetwork([]int{-1, 2})
+				Expect(err).To(Equal(ErrLayerSize))
+			})
+		})
+
 		Context("Given 2 or more layers", func() {
 			It("initializes a random network of the given size", func() {
 				layerEx := [][]int{
@@ -51,6 +65,7 @@ var _ = Describe("Network", func() {
 	Describe("#Prop", func() {
 		var net Network
 		var input, output []float64
+		var err error
 		var inputSize, outputSize int
 
 		BeforeEach(func() {
@@ -62,12 +77,34 @@ var _ = Describe("Network", func() {
 
 		JustBeforeEach(func() {
 			sig := Sigmoid
-			output = net.Prop(input, sig)
+			output, err = net.Prop(input, sig)
 		})
 
 		It("returns the network output", func() {
+			Expect(err).ToNot(HaveOccurred())
 			Expect(len(output)).To(Equal(outputSize))
 		})
+
+		Context("Given an input of the wrong size", func() {
+			BeforeEach(func() {
+				input = append(input, 1)
+			})
+
+			It("returns a shape error", func() {
+				Expect(output).To(BeNil())
+				Expect(errors.Is(err, la.ErrShapeMismatch)).To(BeTrue())
+				Expect(err.Error()).To(Equal(fmt.Sprintf(`Prop: incompatible shapes [10 %d] and [%d]`, inputSize, inputSize+1)))
+			})
+		})
+
+		Context("Given a network without layers", func() {
+			It("returns a shape error", func() {
+				output, err := Network{}.Prop(input, Sigmoid)
+
+				Expect(output).To(BeNil())
+				Expect(errors.Is(err, la.ErrShapeMismatch)).To(BeTrue())
+			})
+		})
 	})
 
 	Describe("#BackProp", func() {
@@ -88,7 +125,7 @@ var _ = Describe("Network", func() {
 			})
 
 			It("returns the gradients", func() {
-				nw, nb := net.BackProp(randEx(inputSize, outputSize), Sigmoid, Quadratic)
+				nw, nb := net.BackProp(ex, Sigmoid, Quadratic)
 
 				Expect(len(nw)).To(Equal(len(net.Weights)))
 				Expect(len(nb)).To(Equal(len(net.Biases)))
@@ -154,6 +191,81 @@ var _ = Describe("Network", func() {
 		})
 	})
 
+	Describe("Per layer activations", func() {
+		var input la.Matrix
+		var net Network
+
+		BeforeEach(func() {
+			input = la.NewMatrix([][]float64{
+				{1, 3, 5, 7},
+				{2, 4, 6, 8},
+			}, false)
+
+			net, _ = NewNetwork([]int{2, 3, 1})
+
+			net.Weights[0] = la.NewMatrix([][]float64{
+				{1, 2},
+				{3, 4},
+				{5, 6},
+			}, false)
+
+			net.Weights[1] = la.NewMatrix([][]float64{{1, 2, 3}}, false)
+
+			net.Biases[0] = []float64{10, 5, 1}
+			net.Biases[1] = []float64{4}
+
+			// the hidden layer falls back to the given activation
+			net.Activations = []Differentiable{nil, Sigmoid}
+		})
+
+		It("applies the activation of each layer when saturating", func() {
+			weighted, activations := net.Saturate(input, aFunc)
+
+			Expect(activations[1]).To(Equal(la.NewMatrix([][]float64{
+				{15 / 3.0, 21 / 3.0, 27 / 3.0, 33 / 3.0},
+				{16 / 3.0, 30 / 3.0, 44 / 3.0, 58 / 3.0},
+				{18 / 3.0, 40 / 3.0, 62 / 3.0, 84 / 3.0},
+			}, false)))
+
+			for j := 0; j < 4; j++ {
+				Expect(*activations[2].At(0, j)).To(Equal(Sigmoid.Fn(*weighted[1].At(0, j))))
+			}
+		})
+
+		It("applies the activation of each layer when propagating", func() {
+			output, _ := net.Prop([]float64{1, 2}, aFunc)
+
+			Expect(output).To(Equal([]float64{Sigmoid.Fn(113 / 3.0)}))
+		})
+
+		It("back propagates with the derivative of each layer", func() {
+			ex := testEx{num: 5}
+			net, _ = NewNetwork([]int{16, 8, 4})
+			inputs := la.NewColMatrix(16, 1, ex.GetInput())
+			desired := la.NewColMatrix(4, 1, ex.GetOutput())
+
+			uniform, _ := net.BackProp(ex, Sigmoid, Quadratic)
+			mUniform, _ := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			net.Activations = []Differentiable{aFunc, Sigmoid}
+
+			// every layer has its own activation so the fallback is never used
+			nw, nb := net.BackProp(ex, Sigmoid, Quadratic)
+			fnw, fnb := net.BackProp(ex, aFunc, Quadratic)
+
+			Expect(fnw).To(Equal(nw))
+			Expect(fnb).To(Equal(nb))
+			Expect(nw[0].Data()).ToNot(Equal(uniform[0].Data()))
+
+			mnw, mnb := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+			mfnw, mfnb := net.MBackProp(inputs, desired, aFunc, Quadratic)
+
+			Expect(mfnw).To(Equal(mnw))
+			Expect(mfnb).To(Equal(mnb))
+			Expect(mnw[0].Data()).ToNot(Equal(mUniform[0].Data()))
+		})
+	})
+
 	Describe("#Delta", func() {
 		var actual, desired, weighted la.Matrix
 
@@ -185,10 +297,10 @@ var _ = Describe("Network", func() {
 			// 5, 10, 15
 			// 16, 20, 24
 
-			Expect(d).To(Equal(la.NewMatrix([][]float64{
-				{5, 5, 5},
-				{4, 4, 4},
-			}, false)))
+			Expect(d.Data()).To(Equal(la.NewMatrix([][]float64{
+				{5, 10, 15},
+				{16, 20, 24},
+			}, false).Data()))
 		})
 	})
 
@@ -220,8 +332,259 @@ var _ = Describe("Network", func() {
 			}
 		})
 	})
+
+	Describe("#Clone", func() {
+		var net Network
+
+		BeforeEach(func() {
+			net, _ = NewNetwork([]int{4, 3, 2})
+			net.Activations = []Differentiable{ReLU}
+			net.Dropout = NewDropout(1, 0.5)
+		})
+
+		It("copies every parameter without sharing them", func() {
+			clone := net.Clone()
+			Expect(clone.Equal(net)).To(BeTrue())
+
+			*clone.Weights[1].At(1, 2) += 1
+			clone.Biases[0][0] += 1
+			clone.Dropout.Keep[0] = 1
+			clone.Activations[0] = Tanh
+
+			Expect(clone.Equal(net)).To(BeFalse())
+			Expect(net.Dropout.Keep).To(Equal([]float64{0.5}))
+			Expect(net.Activations).To(Equal([]Differentiable{ReLU}))
+
+			// the clone drops the same units from a source of its own
+			clone = net.Clone()
+			Expect(clone.Dropout.Rand).ToNot(BeIdenticalTo(net.Dropout.Rand))
+			Expect(clone.Dropout.Rand.Float64()).To(Equal(net.Dropout.Rand.Float64()))
+			clone.Dropout.Rand.Float64()
+			Expect(clone.Dropout.Rand.Float64()).ToNot(Equal(net.Dropout.Rand.Float64()))
+
+			net.Dropout.Rand = rand.New(rand.NewSource(1))
+			Expect(net.Clone().Dropout.Rand).ToNot(BeIdenticalTo(net.Dropout.Rand))
+
+			var copied Network
+			CopyNetwork(&copied, &net)
+			*copied.Weights[0].At(0, 0) += 1
+			Expect(copied.Equal(net)).To(BeFalse())
+		})
+
+		It("compares the parameters whatever their layout", func() {
+			w := net.Weights[0]
+			grid := make([][]float64, w.Shape()[0])
+
+			for i := range grid {
+				grid[i] = w.Row(i)
+			}
+
+			clone := net.Clone()
+			clone.Weights[0] = la.NewMatrix(grid, true)
+			Expect(la.LayoutOf(clone.Weights[0])).ToNot(Equal(la.LayoutOf(w)))
+
+			Expect(clone.Equal(net)).To(BeTrue())
+		})
+
+		It("compares within a tolerance", func() {
+			clone := net.Clone()
+			*clone.Weights[0].At(2, 3) += 1e-9
+			clone.Biases[1][1] -= 1e-9
+
+			Expect(clone.Equal(net)).To(BeFalse())
+			Expect(clone.ApproxEqual(net, 1e-8)).To(BeTrue())
+			Expect(clone.ApproxEqual(net, 1e-10)).To(BeFalse())
+		})
+
+		It("finds networks of different shapes unequal", func() {
+			other, _ := NewNetwork([]int{4, 3, 3})
+			shallow, _ := NewNetwork([]int{4, 2})
+
+			Expect(net.ApproxEqual(other, math.Inf(1))).To(BeFalse())
+			Expect(net.ApproxEqual(shallow, math.Inf(1))).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("Gradients", func() {
+	var net Network
+	var examples []Example
+
+	BeforeEach(func() {
+		r := rand.New(rand.NewSource(7))
+		net, _ = NewNetwork([]int{5, 4, 3})
+
+		for i := range net.Weights {
+			net.Weights[i] = la.NewMatrix(randRows(r, net.Weights[i].Shape()), false)
+		}
+
+		examples = make([]Example, 3)
+
+		for i := range examples {
+			// desired outputs are a distribution so every cost is valid
+			examples[i] = fixedEx{
+				randValues(r, 5),
+				Softmax.VFn(randValues(r, 3)),
+			}
+		}
+	})
+
+	table.DescribeTable("match the finite difference of the cost",
+		func(a, c Differentiable) {
+			net.Activations = []Differentiable{a, a}
+
+			Expect(GradCheck(net, examples[:1], nil, c).Max()).To(BeNumerically(`<`, 1e-6))
+		},
+		gradientEntries()...,
+	)
+
+	table.DescribeTable("are the same from MBackProp and BackProp",
+		func(a, c Differentiable) {
+			net.Activations = []Differentiable{a, a}
+
+			inputs := [][]float64{}
+			outputs := [][]float64{}
+			avgW := make([]la.Matrix, len(net.Weights))
+			avgB := make([][]float64, len(net.Biases))
+
+			for _, e := range examples {
+				inputs = append(inputs, e.GetInput())
+				outputs = append(outputs, e.GetOutput())
+				nw, nb := net.BackProp(e, nil, c)
+
+				for k := range nw {
+					nw[k] = la.MSCALE(nw[k], 1/float64(len(examples)))
+					nb[k] = la.VSCALE(nb[k], 1/float64(len(examples)))
+
+					if avgW[k] == nil {
+						avgW[k], avgB[k] = nw[k], nb[k]
+					} else {
+						avgW[k] = la.MAggD(avgW[k], nw[k], la.SUM)
+						avgB[k] = la.VSUM(avgB[k], nb[k])
+					}
+				}
+			}
+
+			mw, mb := net.MBackProp(columns(inputs), columns(outputs), nil, c)
+
+			for k := range mw {
+				expectClose(rows(mw[k]), rows(avgW[k]))
+				expectClose(mb[k], avgB[k])
+			}
+
+			// a batch of one is exactly BackProp
+			e := examples[0]
+			bw, bb := net.BackProp(e, nil, c)
+			mw, mb = net.MBackProp(
+				columns([][]float64{e.GetInput()}),
+				columns([][]float64{e.GetOutput()}),
+				nil, c)
+
+			for k := range mw {
+				expectClose(rows(mw[k]), rows(bw[k]))
+				expectClose(mb[k], bb[k])
+			}
+		},
+		gradientEntries()...,
+	)
 })
 
+// every activation with quadratic cost and the
+// activations whose output is a valid cross entropy input
+func gradientEntries() []table.TableEntry {
+	activations := map[string]Differentiable{
+		`Sigmoid`:   Sigmoid,
+		`ReLU`:      ReLU,
+		`LeakyReLU`: LeakyReLU,
+		`ELU`:       ELU,
+		`Tanh`:      Tanh,
+		`Softplus`:  Softplus,
+		`GELU`:      GELU,
+		`Swish`:     Swish,
+		`Softmax`:   Softmax,
+	}
+
+	entries := []table.TableEntry{
+		table.Entry(`Sigmoid with CrossEntropy`, Sigmoid, CrossEntropy),
+		table.Entry(`Softmax with CrossEntropy`, Softmax, CrossEntropy),
+		table.Entry(`Sigmoid with CategoricalCrossEntropy`, Sigmoid, CategoricalCrossEntropy),
+		table.Entry(`Softmax with CategoricalCrossEntropy`, Softmax, CategoricalCrossEntropy),
+		table.Entry(`Softplus with CategoricalCrossEntropy`, Softplus, CategoricalCrossEntropy),
+	}
+
+	for _, name := range []string{`Sigmoid`, `ReLU`, `LeakyReLU`, `ELU`, `Tanh`, `Softplus`, `GELU`, `Swish`, `Softmax`} {
+		entries = append(entries, table.Entry(name+` with Quadratic`, activations[name], Quadratic))
+	}
+
+	return entries
+}
+
+func expectClose(actual, expected []float64) {
+	Expect(len(actual)).To(Equal(len(expected)))
+
+	for i := range actual {
+		Expect(actual[i]).To(BeNumerically(`~`, expected[i], 1e-12*math.Max(1, math.Abs(expected[i]))))
+	}
+}
+
+// columns stacks the vectors as the columns of a
+// row major matrix the way SGD builds its mini batches
+func columns(vs [][]float64) la.Matrix {
+	m := la.ZeroMatrix(len(vs[0]), len(vs))
+
+	for j, v := range vs {
+		for i := range v {
+			*m.At(i, j) = v[i]
+		}
+	}
+
+	return m
+}
+
+// rows flattens m in row major order whatever its layout
+func rows(m la.Matrix) []float64 {
+	out := []float64{}
+
+	for i := 0; i < m.Shape()[0]; i++ {
+		out = append(out, m.Row(i)...)
+	}
+
+	return out
+}
+
+func randValues(r *rand.Rand, n int) []float64 {
+	out := make([]float64, n)
+
+	for i := range out {
+		out[i] = r.NormFloat64()
+	}
+
+	return out
+}
+
+func randRows(r *rand.Rand, shape []int) [][]float64 {
+	rows := make([][]float64, shape[0])
+
+	for i := range rows {
+		rows[i] = randValues(r, shape[1])
+	}
+
+	return rows
+}
+
+type fixedEx struct {
+	in  []float64
+	out []float64
+}
+
+func (f fixedEx) GetInput() []float64 {
+	return f.in
+}
+
+func (f fixedEx) GetOutput() []float64 {
+	return f.out
+}
+
 type testX struct {
 	in  int
 	out int
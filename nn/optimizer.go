@@ -0,0 +1,402 @@
+package nn
+
+import (
+	"errors"
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+var ErrMismatchedOptimizerState = errors.New(`Optimizer state does not match the network`)
+
+// An Optimizer applies the gradients returned by Network.MBackProp
+// to the network, updating its weights and biases in place. Any
+// per-parameter state lives in the Optimizer so it must not be
+// shared between networks.
+type Optimizer interface {
+	Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64)
+	// State flattens the per-parameter state into vectors for checkpointing
+	State() [][]float64
+	// SetState restores state previously returned by State for network n
+	SetState(n Network, state [][]float64) error
+}
+
+// buffers hold one value per weight and bias of a network
+type buffers struct {
+	W []la.Matrix
+	B [][]float64
+}
+
+func zeroBuffers(n Network) buffers {
+	b := buffers{
+		W: make([]la.Matrix, len(n.Weights)),
+		B: make([][]float64, len(n.Biases)),
+	}
+
+	for i := range n.Weights {
+		b.W[i] = la.ZeroMatrix(n.Weights[i].Shape()[0], n.Weights[i].Shape()[1])
+		b.B[i] = make([]float64, len(n.Biases[i]))
+	}
+
+	return b
+}
+
+func (b buffers) empty() bool {
+	return len(b.W) == 0
+}
+
+func (b buffers) state() [][]float64 {
+	var out [][]float64
+
+	for i := range b.W {
+		out = append(out, b.W[i].Data(), b.B[i])
+	}
+
+	return out
+}
+
+// setState fills zeroed buffers shaped like n from the
+// vectors returned by state and returns the unused vectors
+func (b *buffers) setState(n Network, state [][]float64) ([][]float64, error) {
+	*b = zeroBuffers(n)
+
+	if len(state) < 2*len(b.W) {
+		return nil, ErrMismatchedOptimizerState
+	}
+
+	for i := range b.W {
+		w, bias := state[2*i], state[2*i+1]
+
+		if len(w) != len(b.W[i].Data()) || len(bias) != len(b.B[i]) {
+			return nil, ErrMismatchedOptimizerState
+		}
+
+		copy(b.W[i].Data(), w)
+		copy(b.B[i], bias)
+	}
+
+	return state[2*len(b.W):], nil
+}
+
+type gradientDescent struct{}
+
+// W += -eta * nablaW
+func (gd gradientDescent) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	for k := range nablaW {
+		la.MAxpy(-eta, nablaW[k], n.Weights[k])
+		la.Axpy(-eta, nablaB[k], n.Biases[k])
+	}
+}
+
+func (gd gradientDescent) State() [][]float64 {
+	return nil
+}
+
+func (gd gradientDescent) SetState(n Network, state [][]float64) error {
+	if len(state) != 0 {
+		return ErrMismatchedOptimizerState
+	}
+
+	return nil
+}
+
+// Momentum accumulates a velocity which decays by Mu every step
+type Momentum struct {
+	Mu       float64
+	velocity buffers
+}
+
+func NewMomentum(mu float64) *Momentum {
+	return &Momentum{Mu: mu}
+}
+
+// v = mu * v - eta * nablaW
+// W += v
+func (m *Momentum) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	if m.velocity.empty() {
+		m.velocity = zeroBuffers(n)
+	}
+
+	v := m.velocity
+
+	velocity := func(v, g float64) float64 {
+		return v*m.Mu + g*-eta
+	}
+
+	for k := range nablaW {
+		la.MAggInto(v.W[k], v.W[k], nablaW[k], velocity)
+		la.AggInto(v.B[k], v.B[k], nablaB[k], velocity)
+
+		la.MAggInto(n.Weights[k], n.Weights[k], v.W[k], la.SUM)
+		la.AggInto(n.Biases[k], n.Biases[k], v.B[k], la.SUM)
+	}
+}
+
+func (m *Momentum) State() [][]float64 {
+	return m.velocity.state()
+}
+
+func (m *Momentum) SetState(n Network, state [][]float64) error {
+	return setAll(n, state, &m.velocity)
+}
+
+// Nesterov is momentum evaluated at the look ahead position
+// W + mu * v, reformulated so the gradient at W can be used
+type Nesterov struct {
+	Mu       float64
+	velocity buffers
+}
+
+func NewNesterov(mu float64) *Nesterov {
+	return &Nesterov{Mu: mu}
+}
+
+// v' = mu * v - eta * nablaW
+// W += -mu * v + (1 + mu) * v'
+func (nm *Nesterov) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	if nm.velocity.empty() {
+		nm.velocity = zeroBuffers(n)
+	}
+
+	v := nm.velocity
+
+	velocity := func(v, g float64) float64 {
+		return v*nm.Mu + g*-eta
+	}
+
+	// the step is taken from the previous velocity
+	// before it is replaced
+	step := func(v, g float64) float64 {
+		return v*-nm.Mu + velocity(v, g)*(1+nm.Mu)
+	}
+
+	for k := range nablaW {
+		la.MAddAggInto(n.Weights[k], v.W[k], nablaW[k], step)
+		la.AddAggInto(n.Biases[k], v.B[k], nablaB[k], step)
+
+		la.MAggInto(v.W[k], v.W[k], nablaW[k], velocity)
+		la.AggInto(v.B[k], v.B[k], nablaB[k], velocity)
+	}
+}
+
+func (nm *Nesterov) State() [][]float64 {
+	return nm.velocity.state()
+}
+
+func (nm *Nesterov) SetState(n Network, state [][]float64) error {
+	return setAll(n, state, &nm.velocity)
+}
+
+// AdaGrad scales each parameter's step by the
+// root of the sum of all its squared gradients
+type AdaGrad struct {
+	Epsilon float64
+	sumSq   buffers
+}
+
+func NewAdaGrad() *AdaGrad {
+	return &AdaGrad{Epsilon: 1e-8}
+}
+
+// G += nablaW^2
+// W += -eta * nablaW / (sqrt(G) + epsilon)
+func (ag *AdaGrad) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	if ag.sumSq.empty() {
+		ag.sumSq = zeroBuffers(n)
+	}
+
+	accumulate := func(s, g float64) float64 {
+		return s + g*g
+	}
+
+	applyScaled(n, nablaW, nablaB, ag.sumSq, accumulate, ag.Epsilon, eta)
+}
+
+func (ag *AdaGrad) State() [][]float64 {
+	return ag.sumSq.state()
+}
+
+func (ag *AdaGrad) SetState(n Network, state [][]float64) error {
+	return setAll(n, state, &ag.sumSq)
+}
+
+// RMSProp is AdaGrad with an exponentially decaying
+// average of squared gradients instead of a sum
+type RMSProp struct {
+	Decay   float64
+	Epsilon float64
+	meanSq  buffers
+}
+
+func NewRMSProp(decay float64) *RMSProp {
+	return &RMSProp{Decay: decay, Epsilon: 1e-8}
+}
+
+// G = decay * G + (1 - decay) * nablaW^2
+// W += -eta * nablaW / (sqrt(G) + epsilon)
+func (r *RMSProp) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	if r.meanSq.empty() {
+		r.meanSq = zeroBuffers(n)
+	}
+
+	accumulate := func(s, g float64) float64 {
+		return r.Decay*s + (1-r.Decay)*g*g
+	}
+
+	applyScaled(n, nablaW, nablaB, r.meanSq, accumulate, r.Epsilon, eta)
+}
+
+func (r *RMSProp) State() [][]float64 {
+	return r.meanSq.state()
+}
+
+func (r *RMSProp) SetState(n Network, state [][]float64) error {
+	return setAll(n, state, &r.meanSq)
+}
+
+// applyScaled accumulates the squared gradients into s then
+// steps every parameter by its gradient over the root of s
+func applyScaled(
+	n Network,
+	nablaW []la.Matrix,
+	nablaB [][]float64,
+	s buffers,
+	accumulate la.BOP,
+	epsilon, eta float64,
+) {
+	scaled := func(g, s float64) float64 {
+		return -eta * g / (math.Sqrt(s) + epsilon)
+	}
+
+	for k := range nablaW {
+		la.MAggInto(s.W[k], s.W[k], nablaW[k], accumulate)
+		la.AggInto(s.B[k], s.B[k], nablaB[k], accumulate)
+
+		la.MAddAggInto(n.Weights[k], nablaW[k], s.W[k], scaled)
+		la.AddAggInto(n.Biases[k], nablaB[k], s.B[k], scaled)
+	}
+}
+
+// Adam keeps bias corrected decaying averages of both the gradients
+// and the squared gradients. A non zero WeightDecay makes it AdamW
+// which shrinks the weights directly rather than through the gradient.
+type Adam struct {
+	Beta1       float64
+	Beta2       float64
+	Epsilon     float64
+	WeightDecay float64
+	mean        buffers
+	meanSq      buffers
+	t           float64
+}
+
+func NewAdam() *Adam {
+	return &Adam{Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+}
+
+func NewAdamW(weightDecay float64) *Adam {
+	a := NewAdam()
+	a.WeightDecay = weightDecay
+	return a
+}
+
+// m = beta1 * m + (1 - beta1) * nablaW
+// v = beta2 * v + (1 - beta2) * nablaW^2
+// W += -eta * (m / (1 - beta1^t)) / (sqrt(v / (1 - beta2^t)) + epsilon)
+// W += -eta * weightDecay * W
+func (a *Adam) Update(n Network, nablaW []la.Matrix, nablaB [][]float64, eta float64) {
+	if a.mean.empty() {
+		a.mean = zeroBuffers(n)
+		a.meanSq = zeroBuffers(n)
+	}
+
+	a.t++
+
+	c1 := 1 - math.Pow(a.Beta1, a.t)
+	c2 := 1 - math.Pow(a.Beta2, a.t)
+
+	mean := func(m, g float64) float64 {
+		return a.Beta1*m + (1-a.Beta1)*g
+	}
+
+	meanSq := func(v, g float64) float64 {
+		return a.Beta2*v + (1-a.Beta2)*g*g
+	}
+
+	step := func(m, v float64) float64 {
+		return -eta * (m / c1) / (math.Sqrt(v/c2) + a.Epsilon)
+	}
+
+	decay := la.MultBy(1 - eta*a.WeightDecay)
+
+	for k := range nablaW {
+		if a.WeightDecay != 0 {
+			la.MMapInto(n.Weights[k], n.Weights[k], decay)
+		}
+
+		la.MAggInto(a.mean.W[k], a.mean.W[k], nablaW[k], mean)
+		la.AggInto(a.mean.B[k], a.mean.B[k], nablaB[k], mean)
+		la.MAggInto(a.meanSq.W[k], a.meanSq.W[k], nablaW[k], meanSq)
+		la.AggInto(a.meanSq.B[k], a.meanSq.B[k], nablaB[k], meanSq)
+
+		la.MAddAggInto(n.Weights[k], a.mean.W[k], a.meanSq.W[k], step)
+		la.AddAggInto(n.Biases[k], a.mean.B[k], a.meanSq.B[k], step)
+	}
+}
+
+// the timestep is stored as a trailing one element vector
+func (a *Adam) State() [][]float64 {
+	if a.mean.empty() {
+		return nil
+	}
+
+	return append(append(a.mean.state(), a.meanSq.state()...), []float64{a.t})
+}
+
+func (a *Adam) SetState(n Network, state [][]float64) error {
+	if len(state) == 0 {
+		a.mean, a.meanSq, a.t = buffers{}, buffers{}, 0
+		return nil
+	}
+
+	rest, e := a.mean.setState(n, state)
+
+	if e != nil {
+		return e
+	}
+
+	rest, e = a.meanSq.setState(n, rest)
+
+	if e != nil {
+		return e
+	}
+
+	if len(rest) != 1 || len(rest[0]) != 1 {
+		return ErrMismatchedOptimizerState
+	}
+
+	a.t = rest[0][0]
+	return nil
+}
+
+// setAll restores optimizers holding a single buffer
+func setAll(n Network, state [][]float64, b *buffers) error {
+	if len(state) == 0 {
+		*b = buffers{}
+		return nil
+	}
+
+	rest, e := b.setState(n, state)
+
+	if e != nil {
+		return e
+	}
+
+	if len(rest) != 0 {
+		return ErrMismatchedOptimizerState
+	}
+
+	return nil
+}
+
+var GradientDescent = gradientDescent{}
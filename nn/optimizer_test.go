@@ -0,0 +1,221 @@
+package nn_test
+
+import (
+	"math"
+	"os"
+
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// gradients of all ones shaped like the network
+func onesLike(n Network) ([]la.Matrix, [][]float64) {
+	nablaW := make([]la.Matrix, len(n.Weights))
+	nablaB := make([][]float64, len(n.Biases))
+
+	for i := range n.Weights {
+		nablaW[i] = la.MMapD(n.Weights[i], func(float64) float64 { return 1 })
+		nablaB[i] = la.Map(n.Biases[i], func(float64) float64 { return 1 })
+	}
+
+	return nablaW, nablaB
+}
+
+var _ = Describe("Optimizer", func() {
+	var net Network
+	var examples []Example
+
+	BeforeEach(func() {
+		net, _ = NewNetwork([]int{16, 8, 4})
+		examples = generateExamples(500)
+	})
+
+	optimizers := map[string]func() Optimizer{
+		`GradientDescent`: func() Optimizer { return GradientDescent },
+		`Momentum`:        func() Optimizer { return NewMomentum(0.9) },
+		`Nesterov`:        func() Optimizer { return NewNesterov(0.9) },
+		`AdaGrad`:         func() Optimizer { return NewAdaGrad() },
+		`RMSProp`:         func() Optimizer { return NewRMSProp(0.9) },
+		`Adam`:            func() Optimizer { return NewAdam() },
+		`AdamW`:           func() Optimizer { return NewAdamW(0.01) },
+	}
+
+	etas := map[string]float64{
+		`GradientDescent`: 3,
+		`Momentum`:        0.3,
+		`Nesterov`:        0.3,
+		`AdaGrad`:         0.1,
+		`RMSProp`:         0.01,
+		`Adam`:            0.01,
+		`AdamW`:           0.01,
+	}
+
+	for name, newOptimizer := range optimizers {
+		name, newOptimizer := name, newOptimizer
+
+		Describe(name, func() {
+			It("lowers the cost of the network", func() {
+				sgd := SGD{
+					Activation: Sigmoid,
+					Cost:       Quadratic,
+					Eta:        etas[name],
+					Net:        net,
+					Optimizer:  newOptimizer(),
+				}
+
+				_, origCost := Evaluate(sgd, examples, binaryMatcher{})
+				sgd.MRun(examples, 5, 10)
+				_, newCost := Evaluate(sgd, examples, binaryMatcher{})
+
+				Expect(newCost).To(BeNumerically(`<`, origCost))
+			})
+
+			It("restores its state from a checkpoint", func() {
+				nablaW, nablaB := onesLike(net)
+				original, restored := newOptimizer(), newOptimizer()
+				a := net.Clone()
+
+				original.Update(a, nablaW, nablaB, 0.1)
+				Expect(restored.SetState(a, original.State())).To(Succeed())
+
+				b := a.Clone()
+				original.Update(a, nablaW, nablaB, 0.1)
+				restored.Update(b, nablaW, nablaB, 0.1)
+
+				for i := range a.Weights {
+					Expect(b.Weights[i].Data()).To(Equal(a.Weights[i].Data()))
+					Expect(b.Biases[i]).To(Equal(a.Biases[i]))
+				}
+			})
+		})
+	}
+
+	Describe("Momentum", func() {
+		Context("Given no momentum", func() {
+			It("is plain gradient descent", func() {
+				nablaW, nablaB := onesLike(net)
+				a, b := net.Clone(), net.Clone()
+
+				for i := 0; i < 3; i++ {
+					NewMomentum(0).Update(a, nablaW, nablaB, 0.5)
+					GradientDescent.Update(b, nablaW, nablaB, 0.5)
+				}
+
+				for i := range a.Weights {
+					Expect(a.Weights[i].Data()).To(Equal(b.Weights[i].Data()))
+				}
+			})
+		})
+	})
+
+	Describe("two steps of all one gradients", func() {
+		// every weight and bias moves by the same amount
+		expectShifted := func(a Network, shift float64) {
+			for i := range a.Weights {
+				for j, w := range a.Weights[i].Data() {
+					ExpectWithOffset(1, w).To(BeNumerically(`~`, net.Weights[i].Data()[j]+shift, 1e-6))
+				}
+
+				for j, b := range a.Biases[i] {
+					ExpectWithOffset(1, b).To(BeNumerically(`~`, net.Biases[i][j]+shift, 1e-6))
+				}
+			}
+		}
+
+		steps := map[string]struct {
+			optimizer func() Optimizer
+			shift     float64
+		}{
+			// v = -0.1 then -0.19, W moves by 1.9v' - 0.9v each step
+			`Nesterov`: {func() Optimizer { return NewNesterov(0.9) }, -0.19 + (0.09 - 1.9*0.19)},
+			// G = 1 then 2
+			`AdaGrad`: {func() Optimizer { return NewAdaGrad() }, -0.1 - 0.1/math.Sqrt(2)},
+			// G = 0.1 then 0.19
+			`RMSProp`: {func() Optimizer { return NewRMSProp(0.9) }, -0.1/math.Sqrt(0.1) - 0.1/math.Sqrt(0.19)},
+		}
+
+		for name, step := range steps {
+			name, step := name, step
+
+			It("moves "+name+" by its update rule", func() {
+				nablaW, nablaB := onesLike(net)
+				o, a := step.optimizer(), net.Clone()
+
+				o.Update(a, nablaW, nablaB, 0.1)
+				o.Update(a, nablaW, nablaB, 0.1)
+
+				expectShifted(a, step.shift)
+			})
+		}
+	})
+
+	Describe("Adam", func() {
+		It("takes a first step of eta in the direction of the gradient", func() {
+			nablaW, nablaB := onesLike(net)
+			a := net.Clone()
+
+			NewAdam().Update(a, nablaW, nablaB, 0.1)
+
+			for i := range a.Weights {
+				for j, w := range a.Weights[i].Data() {
+					Expect(w).To(BeNumerically(`~`, net.Weights[i].Data()[j]-0.1, 1e-6))
+				}
+			}
+		})
+	})
+
+	Describe("AdamW", func() {
+		It("decays the weights independently of the gradient", func() {
+			nablaW, nablaB := onesLike(net)
+
+			for i := range nablaW {
+				nablaW[i] = la.MSCALE(nablaW[i], 0)
+				nablaB[i] = la.VSCALE(nablaB[i], 0)
+			}
+
+			a := net.Clone()
+			NewAdamW(0.5).Update(a, nablaW, nablaB, 0.1)
+
+			for i := range a.Weights {
+				for j, w := range a.Weights[i].Data() {
+					Expect(math.Abs(w)).To(BeNumerically(`~`, 0.95*math.Abs(net.Weights[i].Data()[j]), 1e-9))
+				}
+
+				Expect(a.Biases[i]).To(Equal(net.Biases[i]))
+			}
+		})
+	})
+
+	Describe("#Resume", func() {
+		It("continues with the checkpointed optimizer state", func() {
+			filename := `test-optimizer-checkpoint`
+			defer os.Remove(filename)
+
+			uninterrupted := SGD{
+				Activation: Sigmoid,
+				Cost:       Quadratic,
+				Eta:        0.01,
+				Net:        net.Clone(),
+				Optimizer:  NewAdam(),
+			}
+
+			checkpointed := uninterrupted
+			checkpointed.Net = net.Clone()
+			checkpointed.Optimizer = NewAdam()
+			checkpointed.Checkpoint = &Checkpointer{Filename: filename, Batches: 70}
+
+			Expect(uninterrupted.MRun(examples, 2, 10)).To(Succeed())
+			Expect(checkpointed.MRun(examples, 2, 10)).To(Succeed())
+
+			resumed := SGD{Optimizer: NewAdam()}
+			Expect(resumed.Resume(filename, examples)).To(Succeed())
+
+			for i := range net.Weights {
+				Expect(resumed.Net.Weights[i].Data()).To(Equal(uninterrupted.Net.Weights[i].Data()))
+			}
+		})
+	})
+})
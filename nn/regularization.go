@@ -0,0 +1,106 @@
+package nn
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// Regularization penalizes large parameters of a single layer.
+// Biases are left alone unless Biases is set.
+type Regularization struct {
+	L1     float64
+	L2     float64
+	Biases bool
+}
+
+// A Regularizer holds the Regularization of every layer in a network.
+// Layers past the end of the Regularizer are not penalized.
+type Regularizer []Regularization
+
+// UniformRegularizer applies the same Regularization to every layer
+func UniformRegularizer(numLayers int, r Regularization) Regularizer {
+	out := make(Regularizer, numLayers)
+
+	for i := range out {
+		out[i] = r
+	}
+
+	return out
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+
+	return 0
+}
+
+// l1 * |x| + (l2 / 2) * x^2
+func (r Regularization) penalty(x float64) float64 {
+	return r.L1*math.Abs(x) + (r.L2/2)*x*x
+}
+
+// adds l1 * sign(x) + l2 * x to the gradient g
+func (r Regularization) prime(g, x float64) float64 {
+	return g + r.L1*sign(x) + r.L2*x
+}
+
+// Penalty is the amount added to the cost of the network
+func (r Regularizer) Penalty(n Network) float64 {
+	total := 0.0
+
+	for i := 0; i < len(r) && i < len(n.Weights); i++ {
+		total += la.AddReduce(la.Map(n.Weights[i].Data(), r[i].penalty))
+
+		if r[i].Biases && len(n.Biases[i]) > 0 {
+			total += la.AddReduce(la.Map(n.Biases[i], r[i].penalty))
+		}
+	}
+
+	return total
+}
+
+// addGradient adds the gradient of the penalty to gradients
+// owned by the caller in place
+func (r Regularizer) addGradient(n Network, nablaW []la.Matrix, nablaB [][]float64) {
+	for i := 0; i < len(r) && i < len(nablaW); i++ {
+		la.MAggInto(nablaW[i], nablaW[i], n.Weights[i], r[i].prime)
+
+		if r[i].Biases {
+			la.AggInto(nablaB[i], nablaB[i], n.Biases[i], r[i].prime)
+		}
+	}
+}
+
+// Gradient adds the gradient of the penalty to the
+// cost gradients returned by Network.MBackProp
+func (r Regularizer) Gradient(
+	n Network,
+	nablaW []la.Matrix,
+	nablaB [][]float64,
+) ([]la.Matrix, [][]float64) {
+	if len(r) == 0 {
+		return nablaW, nablaB
+	}
+
+	outW := make([]la.Matrix, len(nablaW))
+	outB := make([][]float64, len(nablaB))
+
+	copy(outW, nablaW)
+	copy(outB, nablaB)
+
+	for i := 0; i < len(r) && i < len(nablaW); i++ {
+		outW[i] = la.MAggD(nablaW[i], n.Weights[i], r[i].prime)
+
+		if r[i].Biases {
+			outB[i] = la.Agg(nablaB[i], n.Biases[i], r[i].prime)
+		}
+	}
+
+	return outW, outB
+}
@@ -0,0 +1,121 @@
+package nn_test
+
+import (
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// the sum of every squared weight in the network
+func sumSquares(n Network) float64 {
+	total := 0.0
+
+	for _, w := range n.Weights {
+		total += la.Dot(w.Data(), w.Data())
+	}
+
+	return total
+}
+
+var _ = Describe("Regularizer", func() {
+	var net Network
+
+	BeforeEach(func() {
+		net, _ = NewNetwork([]int{2, 2, 1})
+
+		net.Weights[0] = la.NewMatrix([][]float64{
+			{1, -2},
+			{0, 3},
+		}, false)
+		net.Weights[1] = la.NewMatrix([][]float64{{-1, 2}}, false)
+
+		net.Biases[0] = []float64{1, -1}
+		net.Biases[1] = []float64{2}
+	})
+
+	Describe("#Penalty", func() {
+		It("adds the L1 and L2 penalty of every layer", func() {
+			r := UniformRegularizer(2, Regularization{L1: 0.5, L2: 2})
+
+			// L1: 0.5 * (6 + 3) = 4.5
+			// L2: 1 * (14 + 5) = 19
+			Expect(r.Penalty(net)).To(Equal(23.5))
+		})
+
+		It("only penalizes the configured layers", func() {
+			r := Regularizer{{L2: 2}}
+
+			Expect(r.Penalty(net)).To(Equal(14.0))
+		})
+
+		It("penalizes biases when asked to", func() {
+			r := Regularizer{{}, {L1: 1, Biases: true}}
+
+			Expect(r.Penalty(net)).To(Equal(5.0))
+		})
+	})
+
+	Describe("#Gradient", func() {
+		It("adds the penalty gradient to the cost gradient", func() {
+			nablaW, nablaB := onesLike(net)
+			r := Regularizer{{L1: 0.5, L2: 2}}
+
+			gw, gb := r.Gradient(net, nablaW, nablaB)
+
+			Expect(gw[0]).To(Equal(la.NewMatrix([][]float64{
+				{3.5, -3.5},
+				{1, 7.5},
+			}, false)))
+
+			// biases and unconfigured layers are untouched
+			Expect(gb[0]).To(Equal([]float64{1, 1}))
+			Expect(gw[1]).To(Equal(nablaW[1]))
+
+			// the original gradients are left alone
+			Expect(nablaW[0].Data()).To(Equal([]float64{1, 1, 1, 1}))
+		})
+
+		It("includes biases when asked to", func() {
+			nablaW, nablaB := onesLike(net)
+			r := Regularizer{{L2: 1, Biases: true}}
+
+			_, gb := r.Gradient(net, nablaW, nablaB)
+
+			Expect(gb[0]).To(Equal([]float64{2, 0}))
+		})
+	})
+
+	Describe("#Evaluate", func() {
+		It("reports the cost with the penalty", func() {
+			examples := generateExamples(50)
+			net, _ = NewNetwork([]int{16, 4})
+			sgd := SGD{Activation: Sigmoid, Cost: Quadratic, Net: net}
+
+			_, cost := Evaluate(sgd, examples, binaryMatcher{})
+
+			sgd.Regularizer = Regularizer{{L2: 0.5}}
+			_, penalized := Evaluate(sgd, examples, binaryMatcher{})
+
+			Expect(penalized).To(BeNumerically(`~`, cost+sumSquares(net)/4, 1e-9))
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("keeps the weights smaller than training without it", func() {
+			examples := generateExamples(500)
+			net, _ = NewNetwork([]int{16, 8, 4})
+
+			plain := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net.Clone()}
+			regularized := plain
+			regularized.Net = net.Clone()
+			regularized.Regularizer = UniformRegularizer(2, Regularization{L2: 0.01})
+
+			plain.MRun(examples, 5, 10)
+			regularized.MRun(examples, 5, 10)
+
+			Expect(sumSquares(regularized.Net)).To(BeNumerically(`<`, sumSquares(plain.Net)))
+		})
+	})
+})
@@ -0,0 +1,429 @@
+package nn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// magic number written at the head of every saved network ("NNET")
+const saveMagicNum uint32 = 0x4E4E4554
+const saveVersion uint32 = 2
+
+// names are short identifiers, anything longer is a corrupt file
+const maxNameSize = 1 << 8
+
+// bounds on the layers of a network, a file describing more is corrupt
+// and is rejected before allocating any of it
+const maxLayers = 1 << 12
+const maxLayerSize = 1 << 24
+const maxLayerParams = 1 << 28
+
+var ErrIncorrectHeader = errors.New(`Incorrect Header Value`)
+var ErrUnsupportedVersion = errors.New(`Unsupported file version`)
+var ErrTruncatedFile = errors.New(`File is truncated`)
+var ErrMismatchedFileSize = errors.New(`File size does not match the network layers`)
+var ErrUnknownDifferentiable = errors.New(`Differentiable is not registered`)
+
+type namedDifferentiable struct {
+	name string
+	d    Differentiable
+}
+
+var registry = []namedDifferentiable{
+	{`sigmoid`, Sigmoid},
+	{`quadratic`, Quadratic},
+	{`cross-entropy`, CrossEntropy},
+	{`relu`, ReLU},
+	{`leaky-relu`, LeakyReLU},
+	{`elu`, ELU},
+	{`tanh`, Tanh},
+	{`softplus`, Softplus},
+	{`gelu`, GELU},
+	{`swish`, Swish},
+	{`softmax`, Softmax},
+	{`categorical-cross-entropy`, CategoricalCrossEntropy},
+}
+
+// Register makes a Differentiable available to Save and Load under
+// the given name. Registering an existing name replaces it.
+func Register(name string, d Differentiable) {
+	for i := range registry {
+		if registry[i].name == name {
+			registry[i].d = d
+			return
+		}
+	}
+
+	registry = append(registry, namedDifferentiable{name, d})
+}
+
+// NameOf returns the name d was registered under
+func NameOf(d Differentiable) (string, error) {
+	if d == nil || !reflect.TypeOf(d).Comparable() {
+		return ``, ErrUnknownDifferentiable
+	}
+
+	for _, nd := range registry {
+		if reflect.TypeOf(nd.d).Comparable() && nd.d == d {
+			return nd.name, nil
+		}
+	}
+
+	return ``, ErrUnknownDifferentiable
+}
+
+// Lookup returns the Differentiable registered under name
+func Lookup(name string) (Differentiable, error) {
+	for _, nd := range registry {
+		if nd.name == name {
+			return nd.d, nil
+		}
+	}
+
+	return nil, ErrUnknownDifferentiable
+}
+
+// Save writes the network along with the names of its activation and
+// cost functions in a versioned big endian binary format:
+//
+//	magic, version
+//	activation name, cost name
+//	number of layer activations (none or one per layer),
+//	name of every layer activation (v2)
+//	number of layers, size of every layer
+//	weights (row major) and biases of every layer
+//
+// Layer activations left nil are written as an empty name.
+func Save(w io.Writer, n Network, activation, cost Differentiable) error {
+	aName, e := NameOf(activation)
+
+	if e != nil {
+		return e
+	}
+
+	cName, e := NameOf(cost)
+
+	if e != nil {
+		return e
+	}
+
+	bw := bufio.NewWriter(w)
+
+	header := []uint32{saveMagicNum, saveVersion}
+
+	if e = binary.Write(bw, binary.BigEndian, header); e != nil {
+		return e
+	}
+
+	if e = writeString(bw, aName); e != nil {
+		return e
+	}
+
+	if e = writeString(bw, cName); e != nil {
+		return e
+	}
+
+	if e = writeActivations(bw, n.layerActivations()); e != nil {
+		return e
+	}
+
+	if e = writeNetwork(bw, n); e != nil {
+		return e
+	}
+
+	return bw.Flush()
+}
+
+// Load reads a network previously written by Save along with its
+// activation and cost functions
+func Load(r io.Reader) (n Network, activation, cost Differentiable, e error) {
+	br := bufio.NewReader(r)
+
+	header := make([]uint32, 2)
+
+	if e = binary.Read(br, binary.BigEndian, header); e != nil {
+		return Network{}, nil, nil, readErr(e)
+	}
+
+	if header[0] != saveMagicNum {
+		return Network{}, nil, nil, ErrIncorrectHeader
+	}
+
+	version := header[1]
+
+	if version < 1 || version > saveVersion {
+		return Network{}, nil, nil, ErrUnsupportedVersion
+	}
+
+	if activation, e = readDifferentiable(br); e != nil {
+		return Network{}, nil, nil, e
+	}
+
+	if cost, e = readDifferentiable(br); e != nil {
+		return Network{}, nil, nil, e
+	}
+
+	var layerActivations []Differentiable
+
+	if version >= 2 {
+		if layerActivations, e = readActivations(br); e != nil {
+			return Network{}, nil, nil, e
+		}
+	}
+
+	if n, e = readNetwork(br); e != nil {
+		return Network{}, nil, nil, e
+	}
+
+	// a file has an activation for every layer or none at all
+	if len(layerActivations) != 0 && len(layerActivations) != len(n.Weights) {
+		return Network{}, nil, nil, ErrMismatchedFileSize
+	}
+
+	n.Activations = layerActivations
+
+	// anything left over means the layer sizes
+	// don't describe the rest of the file
+	if _, e = br.ReadByte(); e != io.EOF {
+		return Network{}, nil, nil, ErrMismatchedFileSize
+	}
+
+	return n, activation, cost, nil
+}
+
+func SaveFile(filename string, n Network, activation, cost Differentiable) error {
+	f, e := os.Create(filename)
+
+	if e != nil {
+		return e
+	}
+
+	if e = Save(f, n, activation, cost); e != nil {
+		f.Close()
+		return e
+	}
+
+	return f.Close()
+}
+
+func LoadFile(filename string) (Network, Differentiable, Differentiable, error) {
+	f, e := os.Open(filename)
+
+	if e != nil {
+		return Network{}, nil, nil, e
+	}
+
+	defer f.Close()
+
+	return Load(f)
+}
+
+func writeNetwork(w io.Writer, n Network) error {
+	sizes := make([]uint32, len(n.Weights)+1)
+
+	if len(n.Weights) > 0 {
+		sizes[0] = uint32(n.Weights[0].Shape()[1])
+	}
+
+	for i, m := range n.Weights {
+		sizes[i+1] = uint32(m.Shape()[0])
+	}
+
+	if e := binary.Write(w, binary.BigEndian, uint32(len(sizes))); e != nil {
+		return e
+	}
+
+	if e := binary.Write(w, binary.BigEndian, sizes); e != nil {
+		return e
+	}
+
+	for i := range n.Weights {
+		if e := binary.Write(w, binary.BigEndian, n.Weights[i].Data()); e != nil {
+			return e
+		}
+
+		if e := binary.Write(w, binary.BigEndian, n.Biases[i]); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func readNetwork(r io.Reader) (Network, error) {
+	var numLayers uint32
+
+	if e := binary.Read(r, binary.BigEndian, &numLayers); e != nil {
+		return Network{}, readErr(e)
+	}
+
+	if numLayers < 2 || numLayers > maxLayers {
+		return Network{}, ErrMismatchedFileSize
+	}
+
+	sizes := make([]uint32, numLayers)
+
+	if e := binary.Read(r, binary.BigEndian, sizes); e != nil {
+		return Network{}, readErr(e)
+	}
+
+	for i, size := range sizes {
+		if size == 0 || size > maxLayerSize {
+			return Network{}, ErrMismatchedFileSize
+		}
+
+		// both sizes are bounded so their product can't overflow
+		if i > 0 && uint64(sizes[i-1])*uint64(size) > maxLayerParams {
+			return Network{}, ErrMismatchedFileSize
+		}
+	}
+
+	n := Network{
+		Weights: make([]la.Matrix, numLayers-1),
+		Biases:  make([][]float64, numLayers-1),
+	}
+
+	for i := range n.Weights {
+		rows, cols := int(sizes[i+1]), int(sizes[i])
+		n.Weights[i] = la.ZeroMatrix(rows, cols)
+		n.Biases[i] = make([]float64, rows)
+
+		if e := binary.Read(r, binary.BigEndian, n.Weights[i].Data()); e != nil {
+			return Network{}, readErr(e)
+		}
+
+		if e := binary.Read(r, binary.BigEndian, n.Biases[i]); e != nil {
+			return Network{}, readErr(e)
+		}
+	}
+
+	return n, nil
+}
+
+// layerActivations is an activation (possibly nil) for every layer,
+// or none if no layer has its own
+func (n Network) layerActivations() []Differentiable {
+	if len(n.Activations) == 0 {
+		return nil
+	}
+
+	out := make([]Differentiable, len(n.Weights))
+	copy(out, n.Activations)
+	return out
+}
+
+func writeActivations(w io.Writer, activations []Differentiable) error {
+	if e := binary.Write(w, binary.BigEndian, uint32(len(activations))); e != nil {
+		return e
+	}
+
+	for _, a := range activations {
+		name := ``
+
+		if a != nil {
+			var e error
+
+			if name, e = NameOf(a); e != nil {
+				return e
+			}
+		}
+
+		if e := writeString(w, name); e != nil {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func readActivations(r io.Reader) ([]Differentiable, error) {
+	var count uint32
+
+	if e := binary.Read(r, binary.BigEndian, &count); e != nil {
+		return nil, readErr(e)
+	}
+
+	if count > maxLayers {
+		return nil, ErrMismatchedFileSize
+	}
+
+	var out []Differentiable
+
+	for i := 0; i < int(count); i++ {
+		name, e := readString(r)
+
+		if e != nil {
+			return nil, e
+		}
+
+		if name == `` {
+			out = append(out, nil)
+			continue
+		}
+
+		a, e := Lookup(name)
+
+		if e != nil {
+			return nil, e
+		}
+
+		out = append(out, a)
+	}
+
+	return out, nil
+}
+
+func writeString(w io.Writer, s string) error {
+	if e := binary.Write(w, binary.BigEndian, uint32(len(s))); e != nil {
+		return e
+	}
+
+	_, e := w.Write([]byte(s))
+	return e
+}
+
+func readString(r io.Reader) (string, error) {
+	var size uint32
+
+	if e := binary.Read(r, binary.BigEndian, &size); e != nil {
+		return ``, readErr(e)
+	}
+
+	if size > maxNameSize {
+		return ``, ErrIncorrectHeader
+	}
+
+	b := make([]byte, size)
+
+	if _, e := io.ReadFull(r, b); e != nil {
+		return ``, readErr(e)
+	}
+
+	return string(b), nil
+}
+
+func readDifferentiable(r io.Reader) (Differentiable, error) {
+	name, e := readString(r)
+
+	if e != nil {
+		return nil, e
+	}
+
+	return Lookup(name)
+}
+
+// io.EOF and io.ErrUnexpectedEOF both mean
+// the file ended before we expected it to
+func readErr(e error) error {
+	if e == io.EOF || e == io.ErrUnexpectedEOF {
+		return ErrTruncatedFile
+	}
+
+	return e
+}
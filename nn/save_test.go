@@ -0,0 +1,195 @@
+package nn_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Save", func() {
+	var net Network
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		net, _ = NewNetwork([]int{12, 7, 3})
+		buf = &bytes.Buffer{}
+	})
+
+	Describe("#Save and #Load", func() {
+		It("round trips the network exactly", func() {
+			Expect(Save(buf, net, Sigmoid, CrossEntropy)).To(Succeed())
+
+			loaded, a, c, err := Load(buf)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(Sigmoid))
+			Expect(c).To(Equal(CrossEntropy))
+			Expect(len(loaded.Weights)).To(Equal(len(net.Weights)))
+
+			for i := range net.Weights {
+				Expect(loaded.Weights[i].Shape()).To(Equal(net.Weights[i].Shape()))
+				Expect(loaded.Weights[i].Data()).To(Equal(net.Weights[i].Data()))
+				Expect(loaded.Biases[i]).To(Equal(net.Biases[i]))
+			}
+		})
+
+		It("round trips the activation of every layer", func() {
+			net.Activations = []Differentiable{nil, Sigmoid}
+
+			Expect(Save(buf, net, Quadratic, Quadratic)).To(Succeed())
+
+			loaded, a, _, err := Load(buf)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(Quadratic))
+			Expect(loaded.Activations).To(Equal([]Differentiable{nil, Sigmoid}))
+		})
+
+		It("saves an activation for every layer", func() {
+			net.Activations = []Differentiable{Tanh}
+
+			Expect(Save(buf, net, Sigmoid, Quadratic)).To(Succeed())
+			loaded, _, _, err := Load(buf)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loaded.Activations).To(Equal([]Differentiable{Tanh, nil}))
+		})
+
+		Context("Given fewer layer activations than layers", func() {
+			It("returns an error", func() {
+				net.Activations = []Differentiable{nil, Sigmoid}
+				Save(buf, net, Sigmoid, Quadratic)
+				data := buf.Bytes()
+
+				// keep only the first, empty, layer activation
+				offset := 8 + (4 + len(`sigmoid`)) + (4 + len(`quadratic`))
+				short := append(append([]byte{}, data[:offset+8]...), data[offset+8+4+len(`sigmoid`):]...)
+				binary.BigEndian.PutUint32(short[offset:], 1)
+
+				_, _, _, err := Load(bytes.NewReader(short))
+				Expect(err).To(Equal(ErrMismatchedFileSize))
+			})
+		})
+
+		It("loads version 1 files without layer activations", func() {
+			Save(buf, net, Sigmoid, Quadratic)
+			data := buf.Bytes()
+
+			// drop the layer activation count which follows
+			// the header and the two names
+			offset := 8 + (4 + len(`sigmoid`)) + (4 + len(`quadratic`))
+			v1 := append(append([]byte{}, data[:offset]...), data[offset+4:]...)
+			binary.BigEndian.PutUint32(v1[4:8], 1)
+
+			loaded, a, _, err := Load(bytes.NewReader(v1))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a).To(Equal(Sigmoid))
+			Expect(loaded.Activations).To(BeEmpty())
+			Expect(loaded.Weights[0].Data()).To(Equal(net.Weights[0].Data()))
+		})
+
+		It("round trips through a file", func() {
+			filename := `test-network-save`
+
+			Expect(SaveFile(filename, net, Sigmoid, Quadratic)).To(Succeed())
+			defer os.Remove(filename)
+
+			loaded, _, c, err := LoadFile(filename)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c).To(Equal(Quadratic))
+			Expect(loaded.Weights[1].Data()).To(Equal(net.Weights[1].Data()))
+		})
+
+		Context("Given an unregistered differentiable", func() {
+			It("returns an error", func() {
+				Expect(Save(buf, net, aFunc, Quadratic)).To(Equal(ErrUnknownDifferentiable))
+
+				Register(`third`, aFunc)
+
+				Expect(Save(buf, net, aFunc, Quadratic)).To(Succeed())
+				_, a, _, err := Load(buf)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(a).To(Equal(aFunc))
+			})
+		})
+
+		Context("Given the header is incorrect", func() {
+			It("returns an error", func() {
+				Save(buf, net, Sigmoid, Quadratic)
+				data := buf.Bytes()
+				binary.BigEndian.PutUint32(data[0:4], 2051)
+
+				_, _, _, err := Load(bytes.NewReader(data))
+				Expect(err).To(Equal(ErrIncorrectHeader))
+			})
+		})
+
+		Context("Given an unknown version", func() {
+			It("returns an error", func() {
+				Save(buf, net, Sigmoid, Quadratic)
+				data := buf.Bytes()
+				binary.BigEndian.PutUint32(data[4:8], 99)
+
+				_, _, _, err := Load(bytes.NewReader(data))
+				Expect(err).To(Equal(ErrUnsupportedVersion))
+			})
+		})
+
+		Context("Given the file is truncated", func() {
+			It("returns an error", func() {
+				Save(buf, net, Sigmoid, Quadratic)
+				data := buf.Bytes()
+
+				for _, size := range []int{0, 6, 20, len(data) - 1} {
+					_, _, _, err := Load(bytes.NewReader(data[:size]))
+					Expect(err).To(Equal(ErrTruncatedFile))
+				}
+			})
+		})
+
+		Context("Given layer sizes that are zero or too large", func() {
+			It("returns an error instead of allocating them", func() {
+				Save(buf, net, Sigmoid, Quadratic)
+				data := buf.Bytes()
+
+				// the number of layers followed by their sizes
+				at := bytes.Index(data, []byte{0, 0, 0, 3, 0, 0, 0, 12, 0, 0, 0, 7, 0, 0, 0, 3})
+				Expect(at).To(BeNumerically(`>`, 0))
+
+				for _, sizes := range [][]uint32{
+					{0xFFFFFFFF, 12, 7, 3},
+					{3, 0xFFFFFFFF, 0xFFFFFFFF, 3},
+					{3, 1 << 24, 1 << 24, 3},
+					{3, 12, 0, 3},
+				} {
+					corrupt := append([]byte{}, data...)
+
+					for i, size := range sizes {
+						binary.BigEndian.PutUint32(corrupt[at+4*i:], size)
+					}
+
+					_, _, _, err := Load(bytes.NewReader(corrupt))
+					Expect(err).To(Equal(ErrMismatchedFileSize))
+				}
+			})
+		})
+
+		Context("Given the file has more data than its layers describe", func() {
+			It("returns an error", func() {
+				Save(buf, net, Sigmoid, Quadratic)
+				buf.Write([]byte{1, 2, 3})
+
+				_, _, _, err := Load(buf)
+				Expect(err).To(Equal(ErrMismatchedFileSize))
+			})
+		})
+	})
+})
@@ -0,0 +1,179 @@
+package nn
+
+import (
+	"math"
+)
+
+// A Schedule decides the learning rate used for every mini batch
+// given the base rate SGD.Eta, the current epoch and the number of
+// mini batches (steps) run since training started
+type Schedule interface {
+	Rate(eta float64, epoch, step int) float64
+}
+
+// progress returns the step when a schedule is advanced
+// per mini batch and the epoch otherwise
+func progress(perBatch bool, epoch, step int) int {
+	if perBatch {
+		return step
+	}
+
+	return epoch
+}
+
+// StepDecay multiplies the rate by Drop every Every epochs
+// (or mini batches if PerBatch is set), every one if Every is 0
+type StepDecay struct {
+	Drop     float64
+	Every    int
+	PerBatch bool
+}
+
+// eta * drop^floor(t / every)
+func (s StepDecay) Rate(eta float64, epoch, step int) float64 {
+	t := progress(s.PerBatch, epoch, step)
+	return eta * math.Pow(s.Drop, float64(t/atLeastOne(s.Every)))
+}
+
+// ExponentialDecay continuously shrinks the rate by a factor of
+// e^-K every epoch (or mini batch if PerBatch is set)
+type ExponentialDecay struct {
+	K        float64
+	PerBatch bool
+}
+
+// eta * e^(-k * t)
+func (ed ExponentialDecay) Rate(eta float64, epoch, step int) float64 {
+	t := progress(ed.PerBatch, epoch, step)
+	return eta * math.Exp(-ed.K*float64(t))
+}
+
+// CosineAnnealing follows half a cosine from eta down to Min over
+// Period epochs (or mini batches) then restarts at eta. Every restart
+// multiplies the length of the period by Mult. Both are 1 if zero.
+type CosineAnnealing struct {
+	Min      float64
+	Period   int
+	Mult     int
+	PerBatch bool
+}
+
+// min + (eta - min) * (1 + cos(pi * t_cur / period_i)) / 2
+func (ca CosineAnnealing) Rate(eta float64, epoch, step int) float64 {
+	t := progress(ca.PerBatch, epoch, step)
+	period := atLeastOne(ca.Period)
+	mult := atLeastOne(ca.Mult)
+
+	// find how far into the current period we are
+	for t >= period {
+		t -= period
+		period *= mult
+	}
+
+	return ca.Min + (eta-ca.Min)*(1+math.Cos(math.Pi*float64(t)/float64(period)))/2
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
+
+// LinearWarmup ramps the rate up from eta / Steps to eta over the first
+// Steps mini batches (or epochs if PerBatch is false) and then defers
+// to Then, or keeps eta if Then is nil
+type LinearWarmup struct {
+	Steps    int
+	PerBatch bool
+	Then     Schedule
+}
+
+func (lw LinearWarmup) Rate(eta float64, epoch, step int) float64 {
+	t := progress(lw.PerBatch, epoch, step)
+
+	if t < lw.Steps {
+		return eta * float64(t+1) / float64(lw.Steps)
+	}
+
+	if lw.Then == nil {
+		return eta
+	}
+
+	return lw.Then.Rate(eta, epoch, step)
+}
+
+// ReduceOnPlateau multiplies the rate by Factor whenever Patience
+// calls to Observe go by without the cost improving on the best
+// cost seen by more than MinDelta. The rate never drops below Min.
+type ReduceOnPlateau struct {
+	Factor   float64
+	Patience int
+	MinDelta float64
+	Min      float64
+	best     float64
+	wait     int
+	scale    float64
+	observed bool
+}
+
+func NewReduceOnPlateau(factor float64, patience int) *ReduceOnPlateau {
+	return &ReduceOnPlateau{Factor: factor, Patience: patience}
+}
+
+// Observe records the cost from an evaluation (e.g. nn.Evaluate),
+// SGD calls it after every run of its Evaluation
+func (rp *ReduceOnPlateau) Observe(cost float64) {
+	if !rp.observed {
+		rp.observed = true
+		rp.best = cost
+		rp.scale = 1
+		return
+	}
+
+	if cost < rp.best-rp.MinDelta {
+		rp.best = cost
+		rp.wait = 0
+		return
+	}
+
+	rp.wait++
+
+	if rp.wait >= rp.Patience {
+		rp.scale *= rp.Factor
+		rp.wait = 0
+	}
+}
+
+// State is the best cost, the wait and scale of the rate and
+// whether a cost has been observed
+func (rp *ReduceOnPlateau) State() [][]float64 {
+	return [][]float64{{rp.best, float64(rp.wait), rp.scale, boolFloat(rp.observed)}}
+}
+
+func (rp *ReduceOnPlateau) SetState(n Network, state [][]float64) error {
+	if len(state) != 1 || len(state[0]) != 4 {
+		return ErrMismatchedState
+	}
+
+	s := state[0]
+	rp.best, rp.wait, rp.scale, rp.observed = s[0], int(s[1]), s[2], s[3] == 1
+	return nil
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func (rp *ReduceOnPlateau) Rate(eta float64, epoch, step int) float64 {
+	if !rp.observed {
+		return eta
+	}
+
+	return math.Max(eta*rp.scale, rp.Min)
+}
@@ -0,0 +1,141 @@
+package nn_test
+
+import (
+	"math"
+
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// the rates of a schedule for every epoch in [0, n)
+func ratesByEpoch(s Schedule, eta float64, n int) []float64 {
+	out := make([]float64, n)
+
+	for i := range out {
+		out[i] = s.Rate(eta, i, i*100)
+	}
+
+	return out
+}
+
+var _ = Describe("Schedule", func() {
+	Describe("StepDecay", func() {
+		It("drops the rate every N epochs", func() {
+			s := StepDecay{Drop: 0.5, Every: 2}
+
+			Expect(ratesByEpoch(s, 4, 6)).To(Equal([]float64{4, 4, 2, 2, 1, 1}))
+		})
+
+		It("can drop the rate every N mini batches", func() {
+			s := StepDecay{Drop: 0.1, Every: 10, PerBatch: true}
+
+			Expect(s.Rate(1, 0, 9)).To(Equal(1.0))
+			Expect(s.Rate(1, 0, 10)).To(BeNumerically(`~`, 0.1, 1e-12))
+		})
+
+		It("drops the rate every epoch when Every is 0", func() {
+			Expect(ratesByEpoch(StepDecay{Drop: 0.5}, 4, 3)).To(Equal([]float64{4, 2, 1}))
+		})
+	})
+
+	Describe("ExponentialDecay", func() {
+		It("decays the rate exponentially", func() {
+			s := ExponentialDecay{K: 0.5}
+
+			for i, r := range ratesByEpoch(s, 2, 5) {
+				Expect(r).To(BeNumerically(`~`, 2*math.Exp(-0.5*float64(i)), 1e-12))
+			}
+		})
+	})
+
+	Describe("CosineAnnealing", func() {
+		It("anneals down to the minimum and restarts", func() {
+			s := CosineAnnealing{Min: 0, Period: 4}
+			rates := ratesByEpoch(s, 1, 8)
+
+			Expect(rates[0]).To(Equal(1.0))
+			Expect(rates[2]).To(BeNumerically(`~`, 0.5, 1e-12))
+			Expect(rates[3]).To(BeNumerically(`<`, rates[2]))
+			Expect(rates[4]).To(Equal(1.0))
+			Expect(rates[6]).To(BeNumerically(`~`, 0.5, 1e-12))
+		})
+
+		It("lengthens every period by the multiplier", func() {
+			s := CosineAnnealing{Min: 0.1, Period: 2, Mult: 2}
+			rates := ratesByEpoch(s, 1, 8)
+
+			// restarts at 0, 2 and 6
+			Expect(rates[2]).To(Equal(1.0))
+			Expect(rates[4]).To(BeNumerically(`~`, 0.55, 1e-12))
+			Expect(rates[6]).To(Equal(1.0))
+		})
+
+		It("restarts every epoch when Period is 0", func() {
+			Expect(ratesByEpoch(CosineAnnealing{Min: 0.1}, 1, 3)).To(Equal([]float64{1, 1, 1}))
+		})
+	})
+
+	Describe("LinearWarmup", func() {
+		It("ramps up to the rate then defers to the next schedule", func() {
+			s := LinearWarmup{
+				Steps:    4,
+				PerBatch: true,
+				Then:     StepDecay{Drop: 0.5, Every: 1},
+			}
+
+			Expect(s.Rate(1, 0, 0)).To(Equal(0.25))
+			Expect(s.Rate(1, 0, 3)).To(Equal(1.0))
+			Expect(s.Rate(1, 0, 4)).To(Equal(1.0))
+			Expect(s.Rate(1, 2, 400)).To(Equal(0.25))
+		})
+	})
+
+	Describe("ReduceOnPlateau", func() {
+		It("reduces the rate when the cost stops improving", func() {
+			s := NewReduceOnPlateau(0.1, 2)
+			s.Min = 0.005
+
+			Expect(s.Rate(1, 0, 0)).To(Equal(1.0))
+
+			for _, cost := range []float64{1, 0.5, 0.6, 0.5} {
+				s.Observe(cost)
+			}
+
+			Expect(s.Rate(1, 4, 0)).To(BeNumerically(`~`, 0.1, 1e-12))
+
+			s.Observe(0.4)
+			Expect(s.Rate(1, 5, 0)).To(BeNumerically(`~`, 0.1, 1e-12))
+
+			for i := 0; i < 10; i++ {
+				s.Observe(1)
+			}
+
+			Expect(s.Rate(1, 15, 0)).To(Equal(0.005))
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("trains with the scheduled rate", func() {
+			net, _ := NewNetwork([]int{16, 4})
+			examples := generateExamples(200)
+
+			fixed := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net.Clone()}
+			scheduled := fixed
+			scheduled.Net = net.Clone()
+			scheduled.Schedule = StepDecay{Drop: 1, Every: 1}
+
+			fixed.MRun(examples, 2, 10)
+			scheduled.MRun(examples, 2, 10)
+
+			Expect(scheduled.Net.Weights[0].Data()).To(Equal(fixed.Net.Weights[0].Data()))
+
+			scheduled.Net = net.Clone()
+			scheduled.Schedule = StepDecay{Drop: 0, Every: 1}
+			scheduled.MRun(examples, 2, 10)
+
+			Expect(scheduled.Net.Weights[0].Data()).ToNot(Equal(fixed.Net.Weights[0].Data()))
+		})
+	})
+})
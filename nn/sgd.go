@@ -2,8 +2,10 @@ package nn
 
 import (
 	"math/rand"
+	"time"
 
 	"github.com/hayden-erickson/neural-network/la"
+	"github.com/hayden-erickson/neural-network/metrics"
 )
 
 type SGD struct {
@@ -11,21 +13,142 @@ type SGD struct {
 	Cost       Differentiable
 	Eta        float64
 	Net        Network
+	// Seed determines the order examples are visited in every epoch
+	Seed       int64
+	Checkpoint *Checkpointer
+	// Optimizer applies the gradients, plain gradient descent if nil
+	Optimizer Optimizer
+	// Schedule varies Eta over the run, Eta is used as is if nil
+	Schedule Schedule
+	// Regularizer penalizes large weights in both
+	// the gradients and the cost reported by Evaluate
+	Regularizer Regularizer
+	// Evaluation is run between epochs when set, when it holds out
+	// part of the training data Seed decides which examples
+	Evaluation *Evaluation
+	// Callbacks are notified as training progresses
+	// and can stop it through the TrainingState
+	Callbacks []Callback
+	// Workers is the number of goroutines each mini batch is split
+	// across, see ParallelMBackProp. 0 or 1 trains on one goroutine.
+	Workers int
 }
 
-func (sgd SGD) MRun(trainingData []Example, epochs, miniBatchSize int) {
-	shuffled := shuffle(trainingData)
-	for i := 0; i < epochs; i++ {
-		for j := 0; j < len(trainingData)/miniBatchSize; j++ {
-			inputs, desired := miniBatchToMatricies(shuffled[(j * miniBatchSize):((j + 1) * miniBatchSize)])
-			deltaW, deltaB := sgd.Net.MBackProp(inputs, desired, sgd.Activation, sgd.Cost)
+// rate is the learning rate for the given epoch and mini batch step
+func (sgd SGD) rate(epoch, step int) float64 {
+	if sgd.Schedule == nil {
+		return sgd.Eta
+	}
+
+	return sgd.Schedule.Rate(sgd.Eta, epoch, step)
+}
+
+func (sgd SGD) optimizer() Optimizer {
+	if sgd.Optimizer == nil {
+		return GradientDescent
+	}
+
+	return sgd.Optimizer
+}
+
+// MRun trains the network using mini batches represented as matricies.
+// If a Checkpoint is configured the training state is periodically
+// written to disk so the run can be continued with Resume.
+func (sgd SGD) MRun(trainingData []Example, epochs, miniBatchSize int) error {
+	return sgd.train(trainingData, 0, 0, epochs, miniBatchSize)
+}
+
+// train runs from the given epoch and mini batch cursor until the
+// given number of epochs have been completed or a callback stops it
+func (sgd SGD) train(trainingData []Example, epoch, batch, epochs, miniBatchSize int) error {
+	trainingData, validation := sgd.Evaluation.split(trainingData, sgd.Seed)
+	numBatches := len(trainingData) / miniBatchSize
+	cbs := callbacks(sgd.Callbacks)
+	start := time.Now()
+
+	state := &TrainingState{
+		Epochs:     epochs,
+		NumBatches: numBatches,
+		Net:        sgd.Net,
+	}
+
+	// every mini batch is back propagated in the same buffers
+	ws := sgd.Net.newWorkspace(sgd.Activation, sgd.Cost, miniBatchSize)
+
+epochs:
+	for i := epoch; i < epochs; i++ {
+		shuffled := shuffle(trainingData, sgd.Seed, i)
+		epochStart := time.Now()
+		totalCost := 0.0
+
+		state.Epoch, state.Batch, state.Step = i, batch, (i*numBatches)+batch
+		state.TrainCost, state.EpochTime = 0, 0
+		cbs.epochStart(state)
 
-			for k, _ := range deltaW {
-				sgd.Net.Weights[k] = la.MSUM(sgd.Net.Weights[k], la.MSCALE(deltaW[k], -sgd.Eta))
-				sgd.Net.Biases[k] = la.VSUM(sgd.Net.Biases[k], la.VSCALE(deltaB[k], -sgd.Eta))
+		if state.Stop {
+			break
+		}
+
+		for j := batch; j < numBatches; j++ {
+			batchStart := time.Now()
+			inputs, desired := ws.load(shuffled[(j * miniBatchSize):((j + 1) * miniBatchSize)])
+			deltaW, deltaB, cost := sgd.Net.parallelMBackProp(ws, inputs, desired, sgd.Activation, sgd.Cost, sgd.Workers, len(cbs) > 0)
+			sgd.Regularizer.addGradient(sgd.Net, deltaW, deltaB)
+			eta := sgd.rate(i, (i*numBatches)+j)
+			sgd.optimizer().Update(sgd.Net, deltaW, deltaB, eta)
+
+			if len(cbs) > 0 {
+				state.BatchCost = cost
+				totalCost += state.BatchCost
+				state.TrainCost = totalCost / float64(j-batch+1)
+				state.Batch, state.Step, state.Eta = j, (i*numBatches)+j, eta
+				state.BatchTime = time.Since(batchStart)
+				state.EpochTime, state.Elapsed = time.Since(epochStart), time.Since(start)
+				cbs.batchEnd(state)
+			}
+
+			if sgd.Checkpoint.due(i, j, numBatches) {
+				// the cursor always points at the next mini batch to run
+				c := sgd.checkpoint(i, j+1, epochs, miniBatchSize)
+
+				if j+1 == numBatches {
+					c.Epoch, c.Batch = i+1, 0
+				}
+
+				if e := sgd.Checkpoint.save(c); e != nil {
+					return e
+				}
+			}
+
+			if state.Stop {
+				break epochs
 			}
 		}
+
+		batch = 0
+
+		if len(validation) > 0 && sgd.Evaluation.due(i) {
+			state.Correct, state.Cost, state.Report = sgd.evaluate(validation)
+			state.Total, state.Evaluated = len(validation), true
+
+			if o, ok := sgd.Schedule.(observer); ok {
+				o.Observe(state.Cost)
+			}
+
+			state.EpochTime, state.Elapsed = time.Since(epochStart), time.Since(start)
+			cbs.evaluated(state)
+		}
+
+		state.EpochTime, state.Elapsed = time.Since(epochStart), time.Since(start)
+		cbs.epochEnd(state)
+
+		if state.Stop {
+			break
+		}
 	}
+
+	cbs.trainEnd(state)
+	return nil
 }
 
 func (sgd SGD) Run(trainingData []Example, epochs, miniBatchSize int) {
@@ -33,8 +156,6 @@ func (sgd SGD) Run(trainingData []Example, epochs, miniBatchSize int) {
 	N := len(trainingData)
 	M := miniBatchSize
 
-	shuffled := shuffle(trainingData)
-
 	totalW := make([]la.Matrix, len(sgd.Net.Weights))
 	totalB := make([][]float64, len(sgd.Net.Biases))
 
@@ -44,10 +165,13 @@ func (sgd SGD) Run(trainingData []Example, epochs, miniBatchSize int) {
 	}
 
 	for e := 0; e < epochs; e++ {
+		shuffled := shuffle(trainingData, sgd.Seed, e)
+
 		// N must be a multiple of miniBatchSize
 		for i := 0; i < (N / M); i++ {
 			resetWeightsAndBiases(&totalW, &totalB)
-			sgd.updateMiniBatch(shuffled[(i*M):((i+1)*M)], totalW, totalB)
+			eta := sgd.rate(e, (e*(N/M))+i)
+			sgd.updateMiniBatch(shuffled[(i*M):((i+1)*M)], totalW, totalB, eta)
 		}
 	}
 }
@@ -101,57 +225,106 @@ func miniBatchToMatricies(exs []Example) (input, desired la.Matrix) {
 	return input, desired
 }
 
-func shuffle(a []Example) []Example {
-	for i := len(a) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-		a[i], a[j] = a[j], a[i]
+// shuffle returns a copy of the examples in an order determined
+// entirely by the seed and epoch so that any epoch can be replayed
+func shuffle(a []Example, seed int64, epoch int) []Example {
+	r := rand.New(rand.NewSource(epochSeed(seed, epoch)))
+	out := make([]Example, len(a))
+	copy(out, a)
+
+	for i := len(out) - 1; i > 0; i-- {
+		j := r.Intn(i + 1)
+		out[i], out[j] = out[j], out[i]
 	}
 
-	return a
+	return out
 }
 
-func (sgd SGD) updateMiniBatch(miniBatch []Example, totalW []la.Matrix, totalB [][]float64) {
+// epochSeed mixes the seed and epoch with a SplitMix64 step so one
+// seed never replays the epochs of another, as seed + epoch would
+func epochSeed(seed int64, epoch int) int64 {
+	s := splitMix{uint64(seed) ^ uint64(epoch)*0xBF58476D1CE4E5B9}
+	return s.Int63()
+}
 
-	for _, e := range miniBatch {
-		nablaW, nablaB := sgd.Net.BackProp(e, sgd.Activation, sgd.Cost)
+func (sgd SGD) updateMiniBatch(miniBatch []Example, totalW []la.Matrix, totalB [][]float64, eta float64) {
 
-		for i := range nablaW {
-			totalW[i] = la.MSUM(totalW[i], nablaW[i])
-			totalB[i] = la.VSUM(totalB[i], nablaB[i])
-		}
+	sgd.Net.sumBackProp(miniBatch, totalW, totalB, sgd.Activation, sgd.Cost, sgd.Workers)
+
+	avgW := make([]la.Matrix, len(totalW))
+	avgB := make([][]float64, len(totalB))
+	overBatch := 1 / float64(len(miniBatch))
+
+	for i := range totalW {
+		avgW[i] = la.MSCALE(totalW[i], overBatch)
+		avgB[i] = la.VSCALE(totalB[i], overBatch)
 	}
 
-	lrOverBatch := -(sgd.Eta / float64(len(miniBatch)))
+	sgd.Regularizer.addGradient(sgd.Net, avgW, avgB)
+	sgd.optimizer().Update(sgd.Net, avgW, avgB, eta)
+}
 
-	for i := range sgd.Net.Weights {
-		sgd.Net.Weights[i] =
-			la.MSUM(sgd.Net.Weights[i],
-				la.MSCALE(totalW[i], lrOverBatch))
+// this returns the average cost per example and accuracy based on the given
+// matcher. the cost includes the penalty of the SGD's Regularizer
+func Evaluate(sgd SGD, testData []Example, matcher la.Matcher) (correct int, cost float64) {
+	return evaluate(sgd, testData, matcher, nil)
+}
+
+// EvaluateReport classifies the test data with the network, computing
+// the confusion matrix over its outputs and the top k accuracy for
+// each of ks
+func EvaluateReport(sgd SGD, testData []Example, ks ...int) *metrics.Report {
+	report := sgd.Net.newReport(ks)
 
-		sgd.Net.Biases[i] =
-			la.VSUM(sgd.Net.Biases[i],
-				la.VSCALE(totalB[i], lrOverBatch))
+	for _, e := range testData {
+		_, act := sgd.Net.forward(e.GetInput(), sgd.Activation)
+		report.Add(act, e.GetOutput())
 	}
+
+	return report
 }
 
-// this returns the Quadratic cost and accuracy based on the given matcher
-func Evaluate(sgd SGD, testData []Example, matcher la.Matcher) (correct int, cost float64) {
-	correct = 0
+// EvaluatePredictions keeps the outputs of the network for the test
+// data to compute ROC curves, log loss and calibration from
+func EvaluatePredictions(sgd SGD, testData []Example) *metrics.Predictions {
+	p := &metrics.Predictions{}
+
+	for _, e := range testData {
+		_, act := sgd.Net.forward(e.GetInput(), sgd.Activation)
+		p.Add(act, e.GetOutput())
+	}
+
+	return p
+}
+
+// newReport creates a report with a class per output of the network
+func (n Network) newReport(ks []int) *metrics.Report {
+	classes, _ := la.Dims(n.Weights[len(n.Weights)-1])
+	return metrics.NewReport(classes, ks...)
+}
+
+// evaluate is Evaluate also adding every output to report if not nil
+func evaluate(sgd SGD, testData []Example, matcher la.Matcher, report *metrics.Report) (correct int, cost float64) {
 	N := len(testData)
 	actual := make([]float64, N)
 
 	for i, e := range testData {
 		desired := e.GetOutput()
-		act := sgd.Net.Prop(e.GetInput(), sgd.Activation)
+		z, act := sgd.Net.forward(e.GetInput(), sgd.Activation)
 		// fmt.Println(act)
 
-		actual[i] = la.AddReduce(la.Agg(act, desired, ToBOP(sgd.Cost.Fn)))
+		outputActivation := sgd.Net.activation(len(sgd.Net.Weights)-1, sgd.Activation)
+		actual[i] = exampleCost(z, act, desired, outputActivation, sgd.Cost)
 
 		if matcher.Match(act, desired) {
 			correct++
 		}
+
+		if report != nil {
+			report.Add(act, desired)
+		}
 	}
 
-	cost = la.AddReduce(actual) / float64(2*N)
+	cost = la.AddReduce(actual)/float64(N) + sgd.Regularizer.Penalty(sgd.Net)
 	return correct, cost
 }
@@ -87,17 +87,117 @@ var _ = Describe("Sgd", func() {
 		})
 
 		It("continually changes the network", func() {
-			oW := make([]la.Matrix, len(sgd.Net.Weights))
-			oB := make([][]float64, len(sgd.Net.Biases))
-
+			// the network is updated in place so its values are copied
 			for i := 0; i < 10; i++ {
-				copy(oW, sgd.Net.Weights)
-				copy(oB, sgd.Net.Biases)
+				oW := rows(sgd.Net.Weights[0])
+				oB := append([]float64{}, sgd.Net.Biases[0]...)
 
 				sgd.MRun(examples, 50, 100)
 
-				Expect(sgd.Net.Weights).ToNot(Equal(oW))
-				Expect(sgd.Net.Biases).ToNot(Equal(oB))
+				Expect(rows(sgd.Net.Weights[0])).ToNot(Equal(oW))
+				Expect(sgd.Net.Biases[0]).ToNot(Equal(oB))
+			}
+		})
+	})
+
+	Describe("from a single seed", func() {
+		// train draws everything random from one seed: the weights,
+		// the dropped units, the examples, their order and the split
+		train := func(seed int64) Network {
+			r := rand.New(rand.NewSource(seed))
+			net, _ := NewNetworkInit(r, []int{16, 8, 4}, Init{Weights: HeNormal})
+			net.Dropout = NewDropout(r.Int63(), 0.8)
+
+			examples := make([]Example, 200)
+
+			for i := range examples {
+				examples[i] = testEx{r.Intn(16)}
+			}
+
+			sgd := SGD{
+				Activation: Sigmoid,
+				Cost:       Quadratic,
+				Eta:        1,
+				Net:        net,
+				Seed:       r.Int63(),
+				Evaluation: &Evaluation{Split: 0.1, Matcher: binaryMatcher{}},
+				Workers:    2,
+			}
+
+			Expect(sgd.MRun(examples, 3, 10)).To(Succeed())
+			return sgd.Net
+		}
+
+		It("trains to identical weights every run", func() {
+			a, b := train(7), train(7)
+
+			Expect(a.Equal(b)).To(BeTrue())
+			Expect(train(8).Equal(a)).To(BeFalse())
+		})
+
+		It("shuffles every epoch of neighbouring seeds differently", func() {
+			examples := generateExamples(50)
+
+			Expect(Shuffle(examples, 7, 1)).To(Equal(Shuffle(examples, 7, 1)))
+			Expect(Shuffle(examples, 7, 1)).ToNot(Equal(Shuffle(examples, 8, 0)))
+			Expect(Shuffle(examples, 7, 0)).ToNot(Equal(Shuffle(examples, 6, 1)))
+		})
+	})
+
+	Describe("mini batch steps", func() {
+		// allocations per run of MRun over the given number of mini batches
+		allocs := func(sgd SGD, batches int) float64 {
+			examples := make([]Example, batches*10)
+
+			// examples which don't allocate their input and output
+			for i := range examples {
+				e := testEx{i % 16}
+				examples[i] = fixedEx{e.GetInput(), e.GetOutput()}
+			}
+
+			return testing.AllocsPerRun(5, func() {
+				sgd.MRun(examples, 1, 10)
+			})
+		}
+
+		It("allocate almost nothing once training has started", func() {
+			net, _ := NewNetwork([]int{16, 8, 4})
+			sgd := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 1, Net: net}
+
+			perStep := (allocs(sgd, 110) - allocs(sgd, 10)) / 100
+			Expect(perStep).To(BeNumerically(`<`, 0.1))
+
+			sgd.Optimizer = NewAdam()
+			sgd.Regularizer = UniformRegularizer(2, Regularization{L2: 0.1})
+			sgd.Net.Dropout = NewDropout(1, 0.5)
+
+			perStep = (allocs(sgd, 110) - allocs(sgd, 10)) / 100
+			Expect(perStep).To(BeNumerically(`<`, 2))
+		})
+
+		It("allocate little more than the goroutines of every shard", func() {
+			net, _ := NewNetwork([]int{16, 8, 4})
+			sgd := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 1, Net: net, Workers: 2}
+			sgd.Net.Dropout = NewDropout(1, 0.5)
+
+			// every shard keeps its workspace, only starting the
+			// goroutines allocates
+			perStep := (allocs(sgd, 110) - allocs(sgd, 10)) / 100
+			Expect(perStep).To(BeNumerically(`<`, 10))
+		})
+	})
+
+	Describe("#EvaluatePredictions", func() {
+		It("keeps the output of Prop for every example", func() {
+			p := EvaluatePredictions(sgd, examples[:50])
+
+			Expect(p.Outputs).To(HaveLen(50))
+
+			for i, e := range examples[:50] {
+				output, _ := sgd.Net.Prop(e.GetInput(), sgd.Activation)
+
+				Expect(p.Outputs[i]).To(Equal(output))
+				Expect(p.Desired[i]).To(Equal(e.GetOutput()))
 			}
 		})
 	})
@@ -181,7 +281,8 @@ func evaluateNet(exs []Example, activation la.OP, n Network) float64 {
 
 	for _, e := range exs {
 		desired = append(desired, e.GetOutput())
-		actual = append(actual, n.Prop(e.GetInput(), Sigmoid))
+		output, _ := n.Prop(e.GetInput(), Sigmoid)
+		actual = append(actual, output)
 	}
 
 	return cost(desired, actual)
@@ -0,0 +1,180 @@
+package nn
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+)
+
+// VectorDifferentiable is implemented by activations where the output
+// of every unit depends on the weighted input of the whole layer. The
+// network applies VFn to each layer instead of mapping Fn over it and
+// back propagates with VPrime instead of Prime.
+type VectorDifferentiable interface {
+	Differentiable
+	VFn(zs []float64) []float64
+	// VPrime multiplies the jacobian of VFn at zs by the
+	// gradient of the cost with respect to the outputs
+	VPrime(zs, grad []float64) []float64
+}
+
+type softmax struct{}
+
+// e^z_i / sum_j(e^z_j) for every i
+// the max is subtracted first so e^z never overflows
+func (s softmax) VFn(zs []float64) []float64 {
+	max := la.Reduce(zs, math.Max)
+	out := la.Map(zs, func(z float64) float64 {
+		return math.Exp(z - max)
+	})
+
+	return la.VSCALE(out, 1/la.AddReduce(out))
+}
+
+// the softmax of the first weighted input given all of them
+func (s softmax) Fn(zs ...float64) float64 {
+	return s.VFn(zs)[0]
+}
+
+// the diagonal of the jacobian s_i * (1 - s_i) for the first weighted
+// input, the network uses the full jacobian through VPrime
+func (s softmax) Prime(zs ...float64) float64 {
+	si := s.Fn(zs...)
+	return si * (1 - si)
+}
+
+// ds_i/dz_j = s_i * (delta_ij - s_j) so
+// grad_z = s * (grad - dot(grad, s))
+func (s softmax) VPrime(zs, grad []float64) []float64 {
+	out := s.VFn(zs)
+	dot := la.Dot(grad, out)
+
+	return la.Agg(out, grad, func(si, gi float64) float64 {
+		return si * (gi - dot)
+	})
+}
+
+type categoricalCrossEntropy struct{}
+
+// -y * log(a), summed over the outputs
+// gives the categorical cross entropy
+func (cce categoricalCrossEntropy) Fn(as ...float64) float64 {
+	actual := as[0]
+	desired := as[1]
+
+	if desired == 0 {
+		return 0
+	}
+
+	return -desired * math.Log(actual)
+}
+
+func (cce categoricalCrossEntropy) Prime(as ...float64) float64 {
+	return -as[1] / as[0]
+}
+
+// logSumExp computes log(sum(e^z)) without overflowing
+func logSumExp(zs []float64) float64 {
+	max := la.Reduce(zs, math.Max)
+	sum := la.AddReduce(la.Map(zs, func(z float64) float64 {
+		return math.Exp(z - max)
+	}))
+
+	return max + math.Log(sum)
+}
+
+// isSoftmaxCCE reports whether the output activation and cost are a
+// softmax with categorical cross entropy, whose delta is a - y
+func isSoftmaxCCE(a, c Differentiable) bool {
+	_, isSoftmax := a.(softmax)
+	_, isCCE := c.(categoricalCrossEntropy)
+	return isSoftmax && isCCE
+}
+
+// isSigmoidCE reports whether the output activation and cost are a
+// sigmoid with cross entropy, whose delta is also a - y
+func isSigmoidCE(a, c Differentiable) bool {
+	_, isSigmoid := a.(sigmoid)
+	_, isCE := c.(crossEntropy)
+	return isSigmoid && isCE
+}
+
+// activate applies a to the weighted input of a whole layer
+func activate(a Differentiable, z []float64) []float64 {
+	if va, ok := a.(VectorDifferentiable); ok {
+		return va.VFn(z)
+	}
+
+	return la.Map(z, ToOP(a.Fn))
+}
+
+// mActivate applies a to every column of a matrix of weighted inputs
+func mActivate(a Differentiable, z la.Matrix) la.Matrix {
+	va, ok := a.(VectorDifferentiable)
+
+	if !ok {
+		return la.MMapD(z, ToOP(a.Fn))
+	}
+
+	out := la.ZeroMatrix(z.Shape()[0], z.Shape()[1])
+
+	for j := 0; j < z.Shape()[1]; j++ {
+		col := va.VFn(z.Col(j))
+
+		for i := range col {
+			*out.At(i, j) = col[i]
+		}
+	}
+
+	return out
+}
+
+// backward turns the gradient of the cost with respect to the
+// activation of a layer into the gradient with respect to its
+// weighted input z
+func backward(a Differentiable, z, grad []float64) []float64 {
+	if va, ok := a.(VectorDifferentiable); ok {
+		return va.VPrime(z, grad)
+	}
+
+	return la.VMULT(grad, la.Map(z, ToOP(a.Prime)))
+}
+
+// mBackward applies backward to every column of z and grad
+func mBackward(a Differentiable, z, grad la.Matrix) la.Matrix {
+	va, ok := a.(VectorDifferentiable)
+
+	if !ok {
+		return la.MMULT(grad, la.MMapD(z, ToOP(a.Prime)))
+	}
+
+	out := la.ZeroMatrix(z.Shape()[0], z.Shape()[1])
+
+	for j := 0; j < z.Shape()[1]; j++ {
+		col := va.VPrime(z.Col(j), grad.Col(j))
+
+		for i := range col {
+			*out.At(i, j) = col[i]
+		}
+	}
+
+	return out
+}
+
+// exampleCost is the cost of a single example given the weighted input
+// and activation of the output layer. The softmax with categorical
+// cross entropy is computed from the weighted input as
+// -sum(y * (z - logSumExp(z))) so a saturated softmax never logs 0.
+func exampleCost(z, actual, desired []float64, a, c Differentiable) float64 {
+	if isSoftmaxCCE(a, c) {
+		lse := logSumExp(z)
+		return -la.AddReduce(la.Agg(z, desired, func(z, y float64) float64 {
+			return y * (z - lse)
+		}))
+	}
+
+	return la.AddReduce(la.Agg(actual, desired, ToBOP(c.Fn)))
+}
+
+var Softmax = softmax{}
+var CategoricalCrossEntropy = categoricalCrossEntropy{}
@@ -0,0 +1,139 @@
+package nn_test
+
+import (
+	"math"
+
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Softmax", func() {
+	Describe("#VFn", func() {
+		It("returns a probability distribution over the layer", func() {
+			out := Softmax.VFn([]float64{1, 2, 3})
+			total := math.Exp(1) + math.Exp(2) + math.Exp(3)
+
+			Expect(out[0]).To(BeNumerically(`~`, math.Exp(1)/total, 1e-12))
+			Expect(out[2]).To(BeNumerically(`~`, math.Exp(3)/total, 1e-12))
+			Expect(la.AddReduce(out)).To(BeNumerically(`~`, 1, 1e-12))
+		})
+
+		It("doesn't overflow for large inputs", func() {
+			out := Softmax.VFn([]float64{1000, 1000, -1000})
+
+			Expect(out).To(Equal([]float64{0.5, 0.5, 0}))
+		})
+	})
+
+	Describe("#Fn", func() {
+		It("is the softmax of the first input", func() {
+			Expect(Softmax.Fn(3, 1, 2)).To(Equal(Softmax.VFn([]float64{3, 1, 2})[0]))
+		})
+	})
+
+	Describe("#Prop", func() {
+		It("applies the softmax to the whole output layer", func() {
+			net, _ := NewNetwork([]int{8, 5, 3})
+			net.Activations = []Differentiable{Sigmoid, Softmax}
+
+			out, _ := net.Prop(la.RandVector(8), Sigmoid)
+
+			Expect(la.AddReduce(out)).To(BeNumerically(`~`, 1, 1e-12))
+		})
+	})
+
+	Describe("#Delta", func() {
+		It("is the actual minus the desired output with categorical cross entropy", func() {
+			actual := la.NewMatrix([][]float64{
+				{0.7, 0.1},
+				{0.3, 0.9},
+			}, false)
+
+			desired := la.NewMatrix([][]float64{
+				{1, 0},
+				{0, 1},
+			}, false)
+
+			d := Delta(actual, desired, la.ZeroMatrix(2, 2), Softmax, CategoricalCrossEntropy)
+
+			Expect(d.Data()[0]).To(BeNumerically(`~`, -0.3, 1e-12))
+			Expect(d.Data()[1]).To(BeNumerically(`~`, 0.1, 1e-12))
+			Expect(d.Data()[2]).To(BeNumerically(`~`, 0.3, 1e-12))
+			Expect(d.Data()[3]).To(BeNumerically(`~`, -0.1, 1e-12))
+		})
+	})
+
+	Describe("#MBackProp", func() {
+		It("agrees with BackProp", func() {
+			ex := testEx{num: 9}
+			net, _ := NewNetwork([]int{16, 6, 4})
+			net.Activations = []Differentiable{Sigmoid, Softmax}
+
+			nw, nb := net.BackProp(ex, Sigmoid, CategoricalCrossEntropy)
+			mnw, mnb := net.MBackProp(
+				la.NewColMatrix(16, 1, ex.GetInput()),
+				la.NewColMatrix(4, 1, ex.GetOutput()),
+				Sigmoid,
+				CategoricalCrossEntropy)
+
+			for i := range nw {
+				for j, w := range nw[i].Data() {
+					Expect(w).To(BeNumerically(`~`, mnw[i].Data()[j], 1e-12))
+				}
+
+				for j, b := range nb[i] {
+					Expect(b).To(BeNumerically(`~`, mnb[i][j], 1e-12))
+				}
+			}
+		})
+	})
+
+	Describe("#Evaluate", func() {
+		It("reports a finite cost when the softmax saturates", func() {
+			net, _ := NewNetwork([]int{16, 4})
+			net.Activations = []Differentiable{Softmax}
+			net.Weights[0] = la.MSCALE(net.Weights[0], 1e6)
+
+			sgd := SGD{Activation: Sigmoid, Cost: CategoricalCrossEntropy, Net: net}
+			_, cost := Evaluate(sgd, generateExamples(50), binaryMatcher{})
+
+			Expect(math.IsInf(cost, 0) || math.IsNaN(cost)).To(BeFalse())
+			Expect(cost).To(BeNumerically(`>`, 0))
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("lowers the cost of a softmax network", func() {
+			// one hot outputs for a 4 class problem
+			examples := make([]Example, 400)
+
+			for i := range examples {
+				examples[i] = oneHotEx{testEx{(i * 7) % 16}}
+			}
+
+			net, _ := NewNetwork([]int{16, 4})
+			net.Activations = []Differentiable{Softmax}
+			sgd := SGD{Activation: Sigmoid, Cost: CategoricalCrossEntropy, Eta: 1, Net: net}
+
+			_, origCost := Evaluate(sgd, examples, binaryMatcher{})
+			sgd.MRun(examples, 10, 10)
+			_, newCost := Evaluate(sgd, examples, binaryMatcher{})
+
+			Expect(newCost).To(BeNumerically(`<`, origCost))
+		})
+	})
+})
+
+// classifies a number by its value mod 4
+type oneHotEx struct {
+	testEx
+}
+
+func (o oneHotEx) GetOutput() []float64 {
+	out := make([]float64, 4)
+	out[o.num%4] = 1
+	return out
+}
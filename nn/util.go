@@ -23,10 +23,11 @@ func (s sigmoid) Prime(zs ...float64) float64 {
 
 type quadratic struct{}
 
+// (y - a)^2 / 2 so that Prime is its derivative
 func (q quadratic) Fn(as ...float64) float64 {
 	actual := as[0]
 	desired := as[1]
-	return math.Pow(desired-actual, 2)
+	return math.Pow(desired-actual, 2) / 2
 
 	// for i, _ := range desired {
 	// 	yMinA := la.VSUB(desired[i], actual[i])
@@ -49,22 +50,50 @@ func (ce crossEntropy) Fn(zs ...float64) float64 {
 	return -desired*math.Log(actual) - (1-desired)*math.Log(1-actual)
 }
 
+// (a - y) / (a * (1 - a))
+// with a sigmoid output this is folded into a - y by Delta
 func (ce crossEntropy) Prime(zs ...float64) float64 {
-	return zs[0] - zs[1]
+	actual := zs[0]
+	desired := zs[1]
+	return (actual - desired) / (actual * (1 - actual))
 }
 
+// ToOP adapts f to a la.OP
 func ToOP(f func(...float64) float64) la.OP {
 	return func(z float64) float64 {
 		return f(z)
 	}
 }
 
+// ToBOP adapts f to a la.BOP
 func ToBOP(f func(...float64) float64) la.BOP {
 	return func(a, b float64) float64 {
 		return f(a, b)
 	}
 }
 
+// bufferedOP is ToOP passing the arguments to f through a buffer
+// owned by the OP, so calling it doesn't allocate but it must not be
+// called from more than one goroutine at a time
+func bufferedOP(f func(...float64) float64) la.OP {
+	args := make([]float64, 1)
+
+	return func(z float64) float64 {
+		args[0] = z
+		return f(args...)
+	}
+}
+
+// bufferedBOP is ToBOP with an argument buffer, see bufferedOP
+func bufferedBOP(f func(...float64) float64) la.BOP {
+	args := make([]float64, 2)
+
+	return func(a, b float64) float64 {
+		args[0], args[1] = a, b
+		return f(args...)
+	}
+}
+
 var Sigmoid = sigmoid{}
 var CrossEntropy = crossEntropy{}
 var Quadratic = quadratic{}
@@ -0,0 +1,177 @@
+package nn
+
+import (
+	"github.com/hayden-erickson/neural-network/la"
+	"github.com/hayden-erickson/neural-network/parallel"
+)
+
+// ParallelMBackProp computes the same average gradients as MBackProp
+// by splitting the columns of input and desired into one shard for
+// each of workers goroutines. The gradients of the shards are combined
+// in shard order so a given number of workers always gives the same
+// result, though it may differ from MBackProp in the last few bits.
+func (n Network) ParallelMBackProp(
+	input la.Matrix,
+	desired la.Matrix,
+	a Differentiable,
+	c Differentiable,
+	workers int,
+) (nablaW []la.Matrix, nablaB [][]float64) {
+	nablaW, nablaB, _ = n.parallelMBackProp(nil, input, desired, a, c, workers, false)
+	return nablaW, nablaB
+}
+
+// parallelMBackProp also returns the average cost of the mini batch
+// when withCost is set. Unless ws is nil the gradients are computed
+// in, and owned by, ws and every shard reuses a workspace of its own
+// kept in ws.
+func (n Network) parallelMBackProp(
+	ws *workspace,
+	input la.Matrix,
+	desired la.Matrix,
+	a Differentiable,
+	c Differentiable,
+	workers int,
+	withCost bool,
+) (nablaW []la.Matrix, nablaB [][]float64, cost float64) {
+	_, N := la.Dims(input)
+	shards := parallel.Shards(N, workers)
+	L := len(n.Weights)
+	outputActivation := n.activation(L-1, a)
+
+	if ws == nil {
+		ws = n.newWorkspace(a, c, N)
+	}
+
+	if shards == 1 {
+		ws.activations[0], ws.desired = input, desired
+		n.mBackPropInto(ws, a, c)
+
+		if withCost {
+			cost = batchCost(ws.zs[L-1], ws.activations[L], desired, outputActivation, c)
+		}
+
+		return ws.nablaW, ws.nablaB, cost
+	}
+
+	parts := ws.split(n, a, c, N, shards)
+	dropouts := n.Dropout.splitInto(ws.dropouts)
+
+	parallel.Range(N, shards, func(k, start, end int) {
+		net := n
+		net.Dropout = dropouts[k]
+		part := parts[k]
+		copyColumns(part.activations[0], input, start)
+		copyColumns(part.desired, desired, start)
+
+		net.mBackPropInto(part, a, c)
+
+		if withCost {
+			part.cost = batchCost(part.zs[L-1], part.activations[L], part.desired, outputActivation, c)
+		}
+	})
+
+	// each shard is an average over its own columns so weight it
+	// by its share of the mini batch, adding them in shard order
+	for i := range ws.nablaW {
+		la.MZero(ws.nablaW[i])
+		la.MapInto(ws.nablaB[i], ws.nablaB[i], zero)
+	}
+
+	for k, part := range parts {
+		share := float64(parallel.Shard(N, shards, k+1)-parallel.Shard(N, shards, k)) / float64(N)
+
+		for i := range ws.nablaW {
+			la.MAxpy(share, part.nablaW[i], ws.nablaW[i])
+			la.Axpy(share, part.nablaB[i], ws.nablaB[i])
+		}
+
+		cost += share * part.cost
+	}
+
+	return ws.nablaW, ws.nablaB, cost
+}
+
+func zero(float64) float64 {
+	return 0
+}
+
+// sumBackProp adds the BackProp gradients of the examples to totalW
+// and totalB, using workers goroutines that each sum a shard of the
+// examples before the shards are added in order
+func (n Network) sumBackProp(examples []Example, totalW []la.Matrix, totalB [][]float64, a, c Differentiable, workers int) {
+	shards := parallel.Shards(len(examples), workers)
+
+	if shards == 1 {
+		addBackProp(n, examples, totalW, totalB, a, c)
+		return
+	}
+
+	partW := make([][]la.Matrix, shards)
+	partB := make([][][]float64, shards)
+	dropouts := n.Dropout.split(shards)
+
+	parallel.Range(len(examples), shards, func(k, start, end int) {
+		net := n
+		net.Dropout = dropouts[k]
+		partW[k], partB[k] = zeroGradients(n)
+
+		addBackProp(net, examples[start:end], partW[k], partB[k], a, c)
+	})
+
+	sumW, sumB := sumGradients(partW, partB)
+
+	for i := range totalW {
+		totalW[i] = la.MSUM(totalW[i], sumW[i])
+		totalB[i] = la.VSUM(totalB[i], sumB[i])
+	}
+}
+
+func addBackProp(n Network, examples []Example, totalW []la.Matrix, totalB [][]float64, a, c Differentiable) {
+	for _, e := range examples {
+		nablaW, nablaB := n.BackProp(e, a, c)
+
+		for i := range nablaW {
+			totalW[i] = la.MSUM(totalW[i], nablaW[i])
+			totalB[i] = la.VSUM(totalB[i], nablaB[i])
+		}
+	}
+}
+
+// sumGradients adds the gradients of every shard in shard order
+func sumGradients(partW [][]la.Matrix, partB [][][]float64) ([]la.Matrix, [][]float64) {
+	nablaW := append([]la.Matrix(nil), partW[0]...)
+	nablaB := append([][]float64(nil), partB[0]...)
+
+	for k := 1; k < len(partW); k++ {
+		for i := range nablaW {
+			nablaW[i] = la.MSUM(nablaW[i], partW[k][i])
+			nablaB[i] = la.VSUM(nablaB[i], partB[k][i])
+		}
+	}
+
+	return nablaW, nablaB
+}
+
+func zeroGradients(n Network) ([]la.Matrix, [][]float64) {
+	w := make([]la.Matrix, len(n.Weights))
+	b := make([][]float64, len(n.Biases))
+
+	for i := range n.Weights {
+		w[i] = la.ZeroMatrix(n.Weights[i].Shape()[0], n.Weights[i].Shape()[1])
+		b[i] = make([]float64, len(n.Biases[i]))
+	}
+
+	return w, b
+}
+
+// copyColumns fills dst with the columns of m from start on
+func copyColumns(dst, m la.Matrix, start int) {
+	rows, cols := la.Dims(dst)
+
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			*dst.At(i, j) = *m.At(i, j+start)
+		}
+	}
+}
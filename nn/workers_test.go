@@ -0,0 +1,106 @@
+package nn_test
+
+import (
+	"math/rand"
+
+	"github.com/hayden-erickson/neural-network/la"
+	. "github.com/hayden-erickson/neural-network/nn"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Workers", func() {
+	var net Network
+	var examples []Example
+
+	BeforeEach(func() {
+		net, _ = NewNetwork([]int{16, 8, 4})
+		net.Activations = []Differentiable{Tanh, Sigmoid}
+		examples = generateExamples(100)
+	})
+
+	Describe("#ParallelMBackProp", func() {
+		var inputs, desired la.Matrix
+
+		BeforeEach(func() {
+			r := rand.New(rand.NewSource(5))
+			ins := make([][]float64, 23)
+			outs := make([][]float64, 23)
+
+			for i := range ins {
+				ins[i] = randValues(r, 16)
+				outs[i] = randValues(r, 4)
+			}
+
+			inputs, desired = columns(ins), columns(outs)
+		})
+
+		It("matches MBackProp for any number of workers", func() {
+			nw, nb := net.MBackProp(inputs, desired, Sigmoid, Quadratic)
+
+			for _, workers := range []int{0, 1, 2, 5, 23, 40} {
+				pw, pb := net.ParallelMBackProp(inputs, desired, Sigmoid, Quadratic, workers)
+
+				for k := range nw {
+					expectClose(rows(pw[k]), rows(nw[k]))
+					expectClose(pb[k], nb[k])
+				}
+			}
+		})
+
+		It("gives exactly the same result every time", func() {
+			pw, pb := net.ParallelMBackProp(inputs, desired, Sigmoid, Quadratic, 4)
+
+			for i := 0; i < 5; i++ {
+				w, b := net.ParallelMBackProp(inputs, desired, Sigmoid, Quadratic, 4)
+
+				Expect(rows(w[0])).To(Equal(rows(pw[0])))
+				Expect(b[1]).To(Equal(pb[1]))
+			}
+		})
+	})
+
+	Describe("#MRun", func() {
+		It("trains reproducibly across workers, even with dropout", func() {
+			first := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net.Clone(), Workers: 4}
+			second := first
+			second.Net = net.Clone()
+
+			first.Net.Dropout = NewDropout(2, 0.8)
+			second.Net.Dropout = NewDropout(2, 0.8)
+
+			first.MRun(examples, 2, 20)
+			second.MRun(examples, 2, 20)
+
+			Expect(rows(first.Net.Weights[0])).To(Equal(rows(second.Net.Weights[0])))
+			Expect(rows(first.Net.Weights[0])).ToNot(Equal(rows(net.Weights[0])))
+		})
+
+		It("trains the same as a single worker", func() {
+			serial := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net.Clone()}
+			parallel := serial
+			parallel.Net = net.Clone()
+			parallel.Workers = 3
+
+			serial.MRun(examples, 2, 20)
+			parallel.MRun(examples, 2, 20)
+
+			expectClose(rows(parallel.Net.Weights[1]), rows(serial.Net.Weights[1]))
+		})
+	})
+
+	Describe("#Run", func() {
+		It("trains the same as a single worker", func() {
+			serial := SGD{Activation: Sigmoid, Cost: Quadratic, Eta: 3, Net: net.Clone()}
+			parallel := serial
+			parallel.Net = net.Clone()
+			parallel.Workers = 3
+
+			serial.Run(examples, 1, 20)
+			parallel.Run(examples, 1, 20)
+
+			expectClose(rows(parallel.Net.Weights[0]), rows(serial.Net.Weights[0]))
+		})
+	})
+})
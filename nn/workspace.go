@@ -0,0 +1,193 @@
+package nn
+
+import (
+	"github.com/hayden-erickson/neural-network/la"
+	"github.com/hayden-erickson/neural-network/parallel"
+)
+
+// A workspace holds every intermediate value of back propagating a
+// mini batch of a fixed size through a network so training can reuse
+// them from one mini batch to the next instead of allocating them.
+// The gradients it returns are overwritten by the next mini batch.
+// Its OPs share argument buffers (see bufferedOP) so a workspace must only
+// be used by one goroutine at a time.
+type workspace struct {
+	// activations[0] is the input, activations[i+1] the output of Weights[i]
+	activations []la.Matrix
+	desired     la.Matrix
+	zs          []la.Matrix
+	deltas      []la.Matrix
+	grads       []la.Matrix
+	masks       []la.Matrix
+	nablaW      []la.Matrix
+	nablaB      [][]float64
+	// fn and prime are the elementwise activation of each layer and
+	// its derivative times the incoming gradient, nil for vector
+	// activations which are applied a column at a time
+	fn     []la.OP
+	prime  []la.BOP
+	cPrime la.BOP
+	// shards back propagate the columns of a mini batch split between
+	// workers, each dropping units with one of dropouts
+	shards   []*workspace
+	dropouts []*Dropout
+	// cost is the average cost of the mini batch of a shard
+	cost float64
+}
+
+func (n Network) newWorkspace(a, c Differentiable, batch int) *workspace {
+	L := len(n.Weights)
+
+	ws := &workspace{
+		activations: make([]la.Matrix, L+1),
+		zs:          make([]la.Matrix, L),
+		deltas:      make([]la.Matrix, L),
+		grads:       make([]la.Matrix, L),
+		masks:       make([]la.Matrix, L),
+		fn:          make([]la.OP, L),
+		prime:       make([]la.BOP, L),
+		cPrime:      bufferedBOP(c.Prime),
+	}
+
+	ws.nablaW, ws.nablaB = zeroGradients(n)
+
+	for i := range n.Weights {
+		rows := n.Weights[i].Shape()[0]
+
+		ws.zs[i] = la.ZeroMatrix(rows, batch)
+		ws.activations[i+1] = la.ZeroMatrix(rows, batch)
+		ws.deltas[i] = la.ZeroMatrix(rows, batch)
+		ws.grads[i] = la.ZeroMatrix(rows, batch)
+
+		if n.Dropout.drops(i) && i < L-1 {
+			ws.masks[i] = la.ZeroMatrix(rows, batch)
+		}
+
+		act := n.activation(i, a)
+
+		if _, ok := act.(VectorDifferentiable); ok {
+			continue
+		}
+
+		ws.fn[i] = bufferedOP(act.Fn)
+		prime := bufferedOP(act.Prime)
+		ws.prime[i] = func(g, z float64) float64 {
+			return g * prime(z)
+		}
+	}
+
+	return ws
+}
+
+// split returns a workspace for each of the shards of a mini batch
+// of N columns, only allocated the first time
+func (ws *workspace) split(n Network, a, c Differentiable, N, shards int) []*workspace {
+	if len(ws.shards) == shards {
+		return ws.shards
+	}
+
+	ws.shards = make([]*workspace, shards)
+	ws.dropouts = make([]*Dropout, shards)
+
+	for k := range ws.shards {
+		cols := parallel.Shard(N, shards, k+1) - parallel.Shard(N, shards, k)
+		part := n.newWorkspace(a, c, cols)
+		part.activations[0] = la.ZeroMatrix(n.Weights[0].Shape()[1], cols)
+		part.desired = la.ZeroMatrix(n.Weights[len(n.Weights)-1].Shape()[0], cols)
+		ws.shards[k] = part
+	}
+
+	return ws.shards
+}
+
+// load copies the examples into the input and desired matrices,
+// which are only allocated the first time
+func (ws *workspace) load(exs []Example) (input, desired la.Matrix) {
+	if ws.activations[0] == nil {
+		ws.activations[0] = la.ZeroMatrix(len(exs[0].GetInput()), len(exs))
+		ws.desired = la.ZeroMatrix(len(exs[0].GetOutput()), len(exs))
+	}
+
+	input, desired = ws.activations[0], ws.desired
+
+	for j, e := range exs {
+		for i, x := range e.GetInput() {
+			*input.At(i, j) = x
+		}
+
+		for i, y := range e.GetOutput() {
+			*desired.At(i, j) = y
+		}
+	}
+
+	return input, desired
+}
+
+// mBackPropInto is mBackProp computed in the buffers of ws
+// for the input and desired set by load or mBackProp
+func (n Network) mBackPropInto(ws *workspace, a, c Differentiable) {
+	L := len(n.Weights)
+
+	// === Propagate forward ===
+	for i := 0; i < L; i++ {
+		z := la.MMDotInto(ws.zs[i], n.Weights[i], ws.activations[i])
+		la.MAddColVector(z, n.Biases[i])
+
+		activation := ws.activations[i+1]
+
+		if ws.fn[i] != nil {
+			la.MMapInto(activation, z, ws.fn[i])
+		} else {
+			copyMatrix(activation, mActivate(n.activation(i, a), z))
+		}
+
+		if ws.masks[i] != nil {
+			la.MAggInto(activation, activation, n.Dropout.maskInto(i, ws.masks[i]), la.MULT)
+		}
+	}
+
+	// === Compute Delta ===
+	actual, delta := ws.activations[L], ws.deltas[L-1]
+	outputActivation := n.activation(L-1, a)
+
+	switch {
+	case isSoftmaxCCE(outputActivation, c) || isSigmoidCE(outputActivation, c):
+		// the activation and cost derivatives cancel to a - y
+		la.MAggInto(delta, actual, ws.desired, la.SUB)
+	case ws.prime[L-1] != nil:
+		la.MAggInto(delta, actual, ws.desired, ws.cPrime)
+		la.MAggInto(delta, delta, ws.zs[L-1], ws.prime[L-1])
+	default:
+		la.MAggInto(ws.grads[L-1], actual, ws.desired, ws.cPrime)
+		copyMatrix(delta, mBackward(outputActivation, ws.zs[L-1], ws.grads[L-1]))
+	}
+
+	la.RowAvgInto(ws.nablaB[L-1], delta)
+	la.MOuterColAvgInto(ws.nablaW[L-1], delta, ws.activations[L-1])
+
+	// === Back Propagate ===
+	for i := L - 2; i >= 0; i-- {
+		grad := la.TMMDotInto(ws.grads[i], n.Weights[i+1], ws.deltas[i+1])
+
+		// dropped units pass no error back
+		if ws.masks[i] != nil {
+			la.MAggInto(grad, grad, ws.masks[i], la.MULT)
+		}
+
+		delta = ws.deltas[i]
+
+		if ws.prime[i] != nil {
+			la.MAggInto(delta, grad, ws.zs[i], ws.prime[i])
+		} else {
+			copyMatrix(delta, mBackward(n.activation(i, a), ws.zs[i], grad))
+		}
+
+		la.RowAvgInto(ws.nablaB[i], delta)
+		la.MOuterColAvgInto(ws.nablaW[i], delta, ws.activations[i])
+	}
+}
+
+// copyMatrix copies the elements of src into dst
+func copyMatrix(dst, src la.Matrix) {
+	la.MMapInto(dst, src, func(x float64) float64 { return x })
+}
@@ -0,0 +1,50 @@
+package parallel
+
+// Range splits [0, n) into at most workers contiguous shards of nearly
+// equal size and calls fn with each shard on its own goroutine,
+// returning once they have all finished. Shard i always covers the
+// same indices for a given n and workers so callers can combine the
+// results of each shard in a fixed order.
+func Range(n, workers int, fn func(shard, start, end int)) {
+	if workers > n {
+		workers = n
+	}
+
+	if workers <= 1 {
+		if n > 0 {
+			fn(0, 0, n)
+		}
+
+		return
+	}
+
+	s := make(Semaphore)
+
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			fn(i, Shard(n, workers, i), Shard(n, workers, i+1))
+			s.Signal()
+		}(i)
+	}
+
+	s.Wait(workers)
+	close(s)
+}
+
+// Shard is the index shard i of Range starts at
+func Shard(n, workers, i int) int {
+	return i * n / workers
+}
+
+// Shards is the number of shards Range splits n indices into
+func Shards(n, workers int) int {
+	if workers > n {
+		workers = n
+	}
+
+	if workers < 1 {
+		return 1
+	}
+
+	return workers
+}
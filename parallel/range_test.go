@@ -0,0 +1,35 @@
+package parallel_test
+
+import (
+	"testing"
+
+	. "github.com/hayden-erickson/neural-network/parallel"
+)
+
+func TestRange(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 4, 10, 20} {
+		n := 10
+		seen := make([]int, n)
+		shards := make([]int, Shards(n, workers))
+
+		Range(n, workers, func(shard, start, end int) {
+			shards[shard] = end - start
+
+			for i := start; i < end; i++ {
+				seen[i]++
+			}
+		})
+
+		for i, s := range seen {
+			if s != 1 {
+				t.Fatalf(`workers %d: index %d visited %d times`, workers, i, s)
+			}
+		}
+
+		for i, size := range shards {
+			if size < n/len(shards) || size > n/len(shards)+1 {
+				t.Fatalf(`workers %d: shard %d has %d indices`, workers, i, size)
+			}
+		}
+	}
+}